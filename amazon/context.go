@@ -14,7 +14,8 @@ const (
 	userKey key = iota
 )
 
-// Endpoint is Amazon's OAuth 2.0 endpoint.
+// Endpoint is Amazon's OAuth 2.0 endpoint in the North America region. Use
+// Region.Endpoint for other regions.
 var Endpoint = oauth2.Endpoint{
 	AuthURL:  "https://www.amazon.com/ap/oa",
 	TokenURL: "https://api.amazon.com/auth/o2/token",
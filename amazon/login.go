@@ -12,6 +12,8 @@ import (
 // Amazon login errors
 var (
 	ErrUnableToGetAmazonUser = errors.New("amazon: unable to get Amazon User")
+	ErrInvalidToken          = errors.New("amazon: access token is invalid")
+	ErrTokenWrongApp         = errors.New("amazon: access token was issued to another app")
 )
 
 // CSRFHandler checks for a state cookie. If found, the state value is read
@@ -36,19 +38,24 @@ func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
 // Amazon access token and User to the ctx. If authentication succeeds,
 // handling delegates to the success handler, otherwise to the failure
 // handler.
+//
+// The access token's audience is checked against the config ClientID and the
+// User is read from the Region of the config Endpoint (see Region.Endpoint).
 func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
 	success = amazonHandler(config, success, failure)
 	return oauth2Login.CallbackHandler(config, success, failure)
 }
 
-// amazonHandler is a http.Handler that gets the OAuth2 Token from the ctx
-// to get the corresponding Amazon User. If successful, the user is added to
-// the ctx and the success handler is called. Otherwise, the failure handler
-// is called.
+// amazonHandler is a http.Handler that gets the OAuth2 Token from the ctx,
+// checks with tokeninfo that it was issued to the config ClientID, and gets
+// the corresponding Amazon User. If successful, the user is added to the ctx
+// and the success handler is called. Otherwise, the failure handler is
+// called.
 func amazonHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
+	baseURL := apiURL(config.Endpoint)
 	fn := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		token, err := oauth2Login.TokenFromContext(ctx)
@@ -58,7 +65,14 @@ func amazonHandler(config *oauth2.Config, success, failure http.Handler) http.Ha
 			return
 		}
 		httpClient := config.Client(ctx, token)
-		amazonService := newClient(httpClient)
+		amazonService := newClient(httpClient, baseURL)
+		info, resp, err := amazonService.TokenInfo(token.AccessToken)
+		err = validateTokenInfo(info, resp, err, config.ClientID)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
 		user, resp, err := amazonService.Profile()
 		err = validateResponse(user, resp, err)
 		if err != nil {
@@ -67,6 +81,7 @@ func amazonHandler(config *oauth2.Config, success, failure http.Handler) http.Ha
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -83,3 +98,26 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// validateTokenInfo returns an error if the given tokenInfo, raw
+// http.Response, or error are unexpected or the token was not issued to the
+// client ID. Returns nil if the token is valid for the client.
+func validateTokenInfo(info *tokenInfo, resp *http.Response, err error, clientID string) error {
+	if err != nil || resp.StatusCode != http.StatusOK || info == nil {
+		return ErrInvalidToken
+	}
+	if info.Audience == "" || info.Audience != clientID {
+		return ErrTokenWrongApp
+	}
+	return nil
+}
+
+// newIdentity returns the Identity of the Amazon User.
+func newIdentity(user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider: "amazon",
+		Subject:  user.ID,
+		Email:    user.Email,
+		Name:     user.Name,
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dghubble/gologin"
@@ -15,27 +16,31 @@ import (
 )
 
 func TestAmazonHandler(t *testing.T) {
-	jsonData := `{"id": "54638001", "name": "Ivy Crimson"}`
-	expectedUser := &User{ID: "54638001", Name: "Ivy Crimson"}
-	proxyClient, server := newAmazonTestServer(jsonData)
+	jsonData := `{"user_id": "amzn1.account.54638001", "name": "Ivy Crimson", "email": "ivy@example.com"}`
+	tokenInfoJSON := `{"iss": "https://www.amazon.com", "user_id": "amzn1.account.54638001", "aud": "client_id", "app_id": "amzn1.application.1", "exp": 3597, "iat": 1311280970}`
+	expectedUser := &User{ID: "amzn1.account.54638001", Name: "Ivy Crimson", Email: "ivy@example.com"}
+	proxyClient, server := newAmazonTestServer("api.amazon.com", jsonData, tokenInfoJSON)
 	defer server.Close()
 	// oauth2 Client will use the proxy client's base Transport
 	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
 	anyToken := &oauth2.Token{AccessToken: "any-token"}
 	ctx = oauth2Login.WithToken(ctx, anyToken)
 
-	config := &oauth2.Config{}
+	config := &oauth2.Config{ClientID: "client_id", Endpoint: Endpoint}
 	success := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		amazonUser, err := UserFromContext(ctx)
 		assert.Nil(t, err)
 		assert.Equal(t, expectedUser, amazonUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "amazon", Subject: "amzn1.account.54638001", Email: "ivy@example.com", Name: "Ivy Crimson"}, identity)
 		fmt.Fprintf(w, "success handler called")
 	}
 	failure := testutils.AssertFailureNotCalled(t)
 
 	// AmazonHandler assert that:
-	// - Token is read from the ctx and passed to the amazon API
+	// - Token is read from the ctx and its audience checked with tokeninfo
 	// - amazon User is obtained from the amazon API
 	// - success handler is called
 	// - amazon User is added to the ctx of the success handler
@@ -46,6 +51,81 @@ func TestAmazonHandler(t *testing.T) {
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
+func TestAmazonHandler_Region(t *testing.T) {
+	jsonData := `{"user_id": "amzn1.account.54638001", "name": "Ivy Crimson"}`
+	tokenInfoJSON := `{"user_id": "amzn1.account.54638001", "aud": "client_id"}`
+	// the mock only serves the Europe API host
+	proxyClient, server := newAmazonTestServer("api.amazon.co.uk", jsonData, tokenInfoJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{ClientID: "client_id", Endpoint: RegionEU.Endpoint()}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		amazonUser, err := UserFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "amzn1.account.54638001", amazonUser.ID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// AmazonHandler with a Europe Endpoint, assert that:
+	// - tokeninfo and the User are read from the Europe API
+	amazonHandler := amazonHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	amazonHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestAmazonHandler_TokenWrongApp(t *testing.T) {
+	jsonData := `{"user_id": "amzn1.account.54638001", "name": "Ivy Crimson"}`
+	tokenInfoJSON := `{"user_id": "amzn1.account.54638001", "aud": "other_client_id"}`
+	proxyClient, server := newAmazonTestServer("api.amazon.com", jsonData, tokenInfoJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: "client_id", Endpoint: Endpoint}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrTokenWrongApp, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler receives a token issued to another app, assert that:
+	// - failure handler is called with ErrTokenWrongApp
+	handler := TokenHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", strings.NewReader("access_token=any-token"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestAmazonHandler_InvalidToken(t *testing.T) {
+	proxyClient, server := newAmazonTestServer("api.amazon.com", `{}`, `{}`)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "invalid-token"})
+
+	config := &oauth2.Config{ClientID: "client_id", Endpoint: Endpoint}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrInvalidToken, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// AmazonHandler with a token tokeninfo rejects, assert that:
+	// - failure handler is called with ErrInvalidToken
+	amazonHandler := amazonHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	amazonHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
 func TestAmazonHandler_MissingCtxToken(t *testing.T) {
 	config := &oauth2.Config{}
 	success := testutils.AssertSuccessNotCalled(t)
@@ -82,14 +162,14 @@ func TestAmazonHandler_ErrorGettingUser(t *testing.T) {
 		ctx := req.Context()
 		err := gologin.ErrorFromContext(ctx)
 		if assert.NotNil(t, err) {
-			assert.Equal(t, ErrUnableToGetAmazonUser, err)
+			assert.Equal(t, ErrInvalidToken, err)
 		}
 		fmt.Fprintf(w, "failure handler called")
 	}
 
-	// AmazonHandler cannot get Amazon User, assert that:
+	// AmazonHandler cannot reach the Amazon API, assert that:
 	// - failure handler is called
-	// - error cannot get Amazon User added to the failure handler ctx
+	// - error invalid token added to the failure handler ctx
 	amazonHandler := amazonHandler(config, success, http.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
@@ -106,3 +186,14 @@ func TestValidateResponse(t *testing.T) {
 	assert.Equal(t, ErrUnableToGetAmazonUser, validateResponse(validUser, invalidResponse, nil))
 	assert.Equal(t, ErrUnableToGetAmazonUser, validateResponse(&User{}, validResponse, nil))
 }
+
+func TestValidateTokenInfo(t *testing.T) {
+	validInfo := &tokenInfo{Audience: "client_id"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 400}
+	assert.Equal(t, nil, validateTokenInfo(validInfo, validResponse, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokenInfo(validInfo, validResponse, fmt.Errorf("Server error"), "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokenInfo(validInfo, invalidResponse, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokenInfo(validInfo, validResponse, nil, "other_client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokenInfo(&tokenInfo{}, validResponse, nil, ""))
+}
@@ -0,0 +1,30 @@
+package amazon
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "amazon" which performs Amazon
+// web logins with the given config, for mounting with a gologin.Registry.
+func Provider(config *oauth2.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+type provider struct {
+	config *oauth2.Config
+}
+
+func (p *provider) Name() string {
+	return "amazon"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, CallbackHandler(p.config, success, failure))
+}
@@ -0,0 +1,54 @@
+package amazon
+
+import (
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	tokenPath = "/auth/o2/token"
+	// defaultAPIURL is the North America API, used if the config Endpoint
+	// has no TokenURL.
+	defaultAPIURL = "https://api.amazon.com"
+)
+
+// Region is a Login with Amazon region. Apps must use the region their
+// customers' accounts belong to.
+//
+// ref: https://developer.amazon.com/docs/login-with-amazon/authorization-code-grant.html
+type Region struct {
+	// AuthURL is the region's authorization URL.
+	AuthURL string
+	// APIURL is the base URL of the region's token, tokeninfo, and profile
+	// APIs.
+	APIURL string
+}
+
+// Login with Amazon regions.
+var (
+	// RegionNA is the North America region.
+	RegionNA = Region{AuthURL: "https://www.amazon.com/ap/oa", APIURL: "https://api.amazon.com"}
+	// RegionEU is the Europe region.
+	RegionEU = Region{AuthURL: "https://eu.account.amazon.com/ap/oa", APIURL: "https://api.amazon.co.uk"}
+	// RegionFE is the Far East region.
+	RegionFE = Region{AuthURL: "https://apac.account.amazon.com/ap/oa", APIURL: "https://api.amazon.co.jp"}
+)
+
+// Endpoint returns the OAuth2 Endpoint of the Region. Set it as the config
+// Endpoint and the handlers read the User from the same Region.
+func (r Region) Endpoint() oauth2.Endpoint {
+	return oauth2.Endpoint{
+		AuthURL:  r.AuthURL,
+		TokenURL: strings.TrimSuffix(r.APIURL, "/") + tokenPath,
+	}
+}
+
+// apiURL returns the base API URL of the Region which issues tokens for the
+// Endpoint.
+func apiURL(endpoint oauth2.Endpoint) string {
+	if endpoint.TokenURL == "" {
+		return defaultAPIURL
+	}
+	return strings.TrimSuffix(endpoint.TokenURL, tokenPath)
+}
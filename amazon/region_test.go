@@ -0,0 +1,24 @@
+package amazon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestRegionEndpoint(t *testing.T) {
+	assert.Equal(t, Endpoint, RegionNA.Endpoint())
+	expected := oauth2.Endpoint{
+		AuthURL:  "https://eu.account.amazon.com/ap/oa",
+		TokenURL: "https://api.amazon.co.uk/auth/o2/token",
+	}
+	assert.Equal(t, expected, RegionEU.Endpoint())
+	assert.Equal(t, "https://api.amazon.co.jp/auth/o2/token", RegionFE.Endpoint().TokenURL)
+}
+
+func TestAPIURL(t *testing.T) {
+	assert.Equal(t, "https://api.amazon.com", apiURL(oauth2.Endpoint{}))
+	assert.Equal(t, "https://api.amazon.com", apiURL(Endpoint))
+	assert.Equal(t, "https://api.amazon.co.jp", apiURL(RegionFE.Endpoint()))
+}
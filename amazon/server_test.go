@@ -9,13 +9,24 @@ import (
 )
 
 // newAmazonTestServer returns a new httptest.Server which mocks the Amazon
-// user endpoint and a client which proxies requests to the server. The server
-// responds with the given json data. The caller must close the server.
-func newAmazonTestServer(jsonData string) (*http.Client, *httptest.Server) {
+// tokeninfo and user profile endpoints of the API host and a client which
+// proxies requests to the server. The tokeninfo endpoint responds with the
+// given tokenInfoJSON for "any-token" and the profile endpoint with the given
+// json data. The caller must close the server.
+func newAmazonTestServer(host, jsonData, tokenInfoJSON string) (*http.Client, *httptest.Server) {
 	client, mux, server := testutils.TestServer()
-	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(host+"/auth/o2/tokeninfo", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, jsonData)
+		if r.URL.Query().Get("access_token") != "any-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_token", "error_description": "The request has an invalid parameter : access_token"}`)
+			return
+		}
+		fmt.Fprint(w, tokenInfoJSON)
+	})
+	mux.HandleFunc(host+"/user/profile", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
 	})
 	return client, server
 }
@@ -0,0 +1,21 @@
+package amazon
+
+import (
+	"net/http"
+
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// TokenHandler receives a Amazon access token obtained by a native (e.g.
+// mobile) client and gets the corresponding Amazon User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are only accepted if
+// tokeninfo reports they were issued to the config ClientID. See
+// oauth2.TokenHandler for the accepted request formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = amazonHandler(config, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
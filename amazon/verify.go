@@ -6,8 +6,6 @@ import (
 	"github.com/dghubble/sling"
 )
 
-const amazonAPI = "https://api.amazon.com/user/"
-
 // User is a Amazon user.
 //
 // Note that user ids are unique to each app.
@@ -18,14 +16,30 @@ type User struct {
 	Location string `json:"postal_code"`
 }
 
+// tokenInfo describes an Amazon access token.
+//
+// ref: https://developer.amazon.com/docs/login-with-amazon/obtain-customer-profile.html
+type tokenInfo struct {
+	// Audience is the client ID the token was issued to.
+	Audience string `json:"aud"`
+	UserID   string `json:"user_id"`
+	AppID    string `json:"app_id"`
+	// ExpiresIn is the token's remaining lifetime in seconds.
+	ExpiresIn int64 `json:"exp"`
+}
+
+type tokenInfoParams struct {
+	AccessToken string `url:"access_token"`
+}
+
 // client is a Amazon client for obtaining the current User.
 type client struct {
 	c     *http.Client
 	sling *sling.Sling
 }
 
-func newClient(httpClient *http.Client) *client {
-	base := sling.New().Client(httpClient).Base(amazonAPI)
+func newClient(httpClient *http.Client, apiURL string) *client {
+	base := sling.New().Client(httpClient).Base(apiURL + "/")
 	return &client{
 		c:     httpClient,
 		sling: base,
@@ -37,6 +51,14 @@ func (c *client) Profile() (*User, *http.Response, error) {
 	// Amazon returns JSON as Content-Type text/javascript :(
 	// Set Accept header to receive proper Content-Type application/json
 	// so Sling will decode into the struct
-	resp, err := c.sling.New().Set("Accept", "application/json").Get("profile").ReceiveSuccess(user)
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("user/profile").ReceiveSuccess(user)
 	return user, resp, err
 }
+
+// TokenInfo gets the tokenInfo of the access token.
+func (c *client) TokenInfo(accessToken string) (*tokenInfo, *http.Response, error) {
+	info := new(tokenInfo)
+	params := &tokenInfoParams{AccessToken: accessToken}
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("auth/o2/tokeninfo").QueryStruct(params).ReceiveSuccess(info)
+	return info, resp, err
+}
@@ -0,0 +1,184 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Azure Active Directory cloud instances.
+//
+// ref: https://docs.microsoft.com/en-us/azure/active-directory/develop/authentication-national-cloud
+const (
+	PublicCloud       = "https://login.microsoftonline.com"
+	USGovernmentCloud = "https://login.microsoftonline.us"
+	ChinaCloud        = "https://login.chinacloudapi.cn"
+)
+
+// Azure Active Directory tenants which accept users from many tenants.
+const (
+	// TenantCommon accepts work, school, and personal Microsoft accounts.
+	TenantCommon = "common"
+	// TenantOrganizations accepts work and school accounts.
+	TenantOrganizations = "organizations"
+	// TenantConsumers accepts personal Microsoft accounts.
+	TenantConsumers = "consumers"
+)
+
+// ConsumerTenantID is the tenant ID of personal Microsoft accounts.
+const ConsumerTenantID = "9188040d-6c67-4c5b-b112-36a304b66dad"
+
+// tenantIDPlaceholder appears in the issuer of multi-tenant discovery
+// documents in place of the tenant ID of each token.
+const tenantIDPlaceholder = "{tenantid}"
+
+// Azure ID Token verification errors
+var (
+	ErrTenantNotAllowed = errors.New("azure: ID Token tenant is not allowed")
+	ErrInvalidIssuer    = errors.New("azure: ID Token issuer does not match its tenant")
+)
+
+// Authority identifies the Azure Active Directory cloud and tenant which
+// authenticate users.
+type Authority struct {
+	// Cloud is the cloud instance. Defaults to PublicCloud.
+	Cloud string
+	// Tenant is a tenant ID or domain (e.g. "contoso.onmicrosoft.com") or one
+	// of TenantCommon, TenantOrganizations, or TenantConsumers. Defaults to
+	// ConsumerTenantID.
+	Tenant string
+	// AllowedTenants lists the tenant IDs whose users may log in. If empty,
+	// users from any tenant the Tenant accepts may log in, which is only
+	// appropriate for multi-tenant apps open to everyone.
+	AllowedTenants []string
+}
+
+// URL returns the base URL of the Authority's v2.0 endpoints.
+func (a Authority) URL() string {
+	cloud := a.Cloud
+	if cloud == "" {
+		cloud = PublicCloud
+	}
+	tenant := a.Tenant
+	if tenant == "" {
+		tenant = ConsumerTenantID
+	}
+	return strings.TrimSuffix(cloud, "/") + "/" + tenant + "/v2.0"
+}
+
+// Endpoint returns the OAuth2 v2.0 Endpoint of the Authority.
+func (a Authority) Endpoint() oauth2.Endpoint {
+	base := strings.TrimSuffix(a.URL(), "/v2.0")
+	return oauth2.Endpoint{
+		AuthURL:  base + "/oauth2/v2.0/authorize",
+		TokenURL: base + "/oauth2/v2.0/token",
+	}
+}
+
+// NewVerifier uses OpenID Connect discovery to return an oidc.Verifier of ID
+// Tokens issued by the Authority for the client ID. Tokens must be issued by
+// the tenant named in their "tid" claim and, if set, one of the
+// AllowedTenants. The ctx is retained to fetch signing keys.
+func NewVerifier(ctx context.Context, authority Authority, clientID string) (oidc.Verifier, error) {
+	metadata, err := discover(ctx, authority.URL())
+	if err != nil {
+		return nil, err
+	}
+	// multi-tenant issuers are templates, so check issuers per token
+	keySet := goidc.NewRemoteKeySet(ctx, metadata.JWKSURL)
+	verifier := goidc.NewVerifier(metadata.Issuer, keySet, &goidc.Config{
+		ClientID:        clientID,
+		SkipIssuerCheck: true,
+	})
+	return &tenantVerifier{
+		verifier: verifier,
+		issuer:   metadata.Issuer,
+		allowed:  authority.AllowedTenants,
+	}, nil
+}
+
+// tenantVerifier verifies an ID Token's signature and claims, then checks its
+// issuer and tenant.
+type tenantVerifier struct {
+	verifier *goidc.IDTokenVerifier
+	// issuer may contain the tenantIDPlaceholder
+	issuer  string
+	allowed []string
+}
+
+func (v *tenantVerifier) Verify(ctx context.Context, rawIDToken string) (*goidc.IDToken, error) {
+	idToken, err := v.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	var claims struct {
+		TenantID string `json:"tid"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	if claims.TenantID == "" {
+		return nil, ErrInvalidIssuer
+	}
+	expected := strings.Replace(v.issuer, tenantIDPlaceholder, claims.TenantID, 1)
+	if idToken.Issuer != expected {
+		return nil, ErrInvalidIssuer
+	}
+	if len(v.allowed) > 0 && !contains(v.allowed, claims.TenantID) {
+		return nil, ErrTenantNotAllowed
+	}
+	return idToken, nil
+}
+
+// providerMetadata is the subset of the OpenID Connect discovery document
+// needed to verify ID Tokens.
+type providerMetadata struct {
+	Issuer  string `json:"issuer"`
+	JWKSURL string `json:"jwks_uri"`
+}
+
+// discover fetches the OpenID Connect discovery document of the issuer URL.
+// Unlike goidc.NewProvider, the document's issuer may differ from the URL,
+// since multi-tenant documents use an issuer template.
+func discover(ctx context.Context, issuerURL string) (*providerMetadata, error) {
+	req, err := http.NewRequest("GET", issuerURL+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	client := http.DefaultClient
+	if c, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		client = c
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("azure: discovery request failed: %s", resp.Status)
+	}
+	metadata := new(providerMetadata)
+	if err := json.NewDecoder(resp.Body).Decode(metadata); err != nil {
+		return nil, fmt.Errorf("azure: unable to decode discovery document: %v", err)
+	}
+	if metadata.Issuer == "" || metadata.JWKSURL == "" {
+		return nil, errors.New("azure: discovery document missing issuer or jwks_uri")
+	}
+	return metadata, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const otherTenantID = "f8cdef31-a31e-4b4a-93e4-5f571e91255a"
+
+func TestAuthority_Endpoint(t *testing.T) {
+	cases := []struct {
+		authority Authority
+		url       string
+		authURL   string
+	}{
+		{Authority{}, "https://login.microsoftonline.com/" + ConsumerTenantID + "/v2.0", "https://login.microsoftonline.com/" + ConsumerTenantID + "/oauth2/v2.0/authorize"},
+		{Authority{Tenant: TenantCommon}, "https://login.microsoftonline.com/common/v2.0", "https://login.microsoftonline.com/common/oauth2/v2.0/authorize"},
+		{Authority{Cloud: USGovernmentCloud, Tenant: testTenantID}, "https://login.microsoftonline.us/" + testTenantID + "/v2.0", "https://login.microsoftonline.us/" + testTenantID + "/oauth2/v2.0/authorize"},
+		{Authority{Cloud: ChinaCloud + "/", Tenant: TenantOrganizations}, "https://login.chinacloudapi.cn/organizations/v2.0", "https://login.chinacloudapi.cn/organizations/oauth2/v2.0/authorize"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.url, c.authority.URL())
+		assert.Equal(t, c.authURL, c.authority.Endpoint().AuthURL)
+	}
+	assert.Equal(t, "https://login.microsoftonline.com/common/oauth2/v2.0/token", Authority{Tenant: TenantCommon}.Endpoint().TokenURL)
+}
+
+func TestNewVerifier(t *testing.T) {
+	a := newAzureTestServer(t)
+	defer a.Close()
+	ctx := context.Background()
+
+	cases := []struct {
+		authority Authority
+		issuer    string
+		tenantID  string
+		expected  error
+	}{
+		// single tenant
+		{Authority{Tenant: testTenantID}, a.Issuer(testTenantID), testTenantID, nil},
+		{Authority{Tenant: "contoso.onmicrosoft.com"}, a.Issuer(testTenantID), testTenantID, nil},
+		{Authority{Tenant: testTenantID}, a.Issuer(otherTenantID), otherTenantID, ErrInvalidIssuer},
+		// multi-tenant
+		{Authority{Tenant: TenantCommon}, a.Issuer(testTenantID), testTenantID, nil},
+		{Authority{Tenant: TenantOrganizations, AllowedTenants: []string{testTenantID}}, a.Issuer(testTenantID), testTenantID, nil},
+		{Authority{Tenant: TenantOrganizations, AllowedTenants: []string{testTenantID}}, a.Issuer(otherTenantID), otherTenantID, ErrTenantNotAllowed},
+		// issuer must match the token's tenant
+		{Authority{Tenant: TenantCommon}, a.Issuer(otherTenantID), testTenantID, ErrInvalidIssuer},
+		{Authority{Tenant: TenantCommon}, a.Issuer(testTenantID), "", ErrInvalidIssuer},
+	}
+	for _, c := range cases {
+		c.authority.Cloud = a.server.URL
+		verifier, err := NewVerifier(ctx, c.authority, testClientID)
+		if !assert.Nil(t, err) {
+			continue
+		}
+		idToken, err := verifier.Verify(ctx, a.IDToken(t, c.issuer, c.tenantID, nil))
+		assert.Equal(t, c.expected, err)
+		if c.expected == nil && assert.NotNil(t, idToken) {
+			assert.Equal(t, c.issuer, idToken.Issuer)
+		}
+	}
+}
+
+func TestNewVerifier_DiscoveryError(t *testing.T) {
+	a := newAzureTestServer(t)
+	defer a.Close()
+	_, err := NewVerifier(context.Background(), Authority{Cloud: a.server.URL + "/missing"}, testClientID)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,114 @@
+package azure
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// Microsoft Graph errors
+var (
+	ErrUnableToGetGraphUser = errors.New("azure: unable to get Microsoft Graph user")
+	ErrUnableToGetPhoto     = errors.New("azure: unable to get Microsoft Graph photo")
+	ErrNoPhoto              = errors.New("azure: user has no photo")
+	// ErrGroupsForbidden is returned on a group overage when the access token
+	// may not list the user's groups, so the User's Groups are unknown.
+	ErrGroupsForbidden = errors.New("azure: not permitted to get Microsoft Graph groups")
+)
+
+// GraphURL returns the Microsoft Graph endpoint of the Authority's cloud.
+func (a Authority) GraphURL() string {
+	switch strings.TrimSuffix(a.Cloud, "/") {
+	case USGovernmentCloud:
+		return USGovernmentGraph
+	case ChinaCloud:
+		return ChinaGraph
+	}
+	return PublicGraph
+}
+
+// GraphHandler enriches the Azure User in the ctx with the user's object ID
+// and job title from Microsoft Graph /me. If the ID Token omitted the user's
+// groups because there were too many (a group overage), they are read from
+// /me/memberOf. Chain it as the success handler of CallbackHandler. If
+// successful, the enriched User and its Identity are added to the ctx and the
+// success handler is called. Otherwise, the failure handler is called.
+//
+// The access token must be for Microsoft Graph, so request the "User.Read"
+// scope. To read groups on overage, also request "GroupMember.Read.All";
+// without it, overage logins fail with ErrGroupsForbidden. Note
+// /me/memberOf lists direct memberships only, while ID Token group claims
+// include transitive memberships. If graphURL is empty, PublicGraph is used.
+func GraphHandler(config *oauth2.Config, graphURL string, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		graphClient := newClient(config.Client(ctx, token), graphURL)
+		me, resp, err := graphClient.Me()
+		if err = validateResponse(me, resp, err); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		// copy so the User from the ID Token is not modified
+		enriched := *user
+		if enriched.ObjectID == "" {
+			enriched.ObjectID = me.ID
+		}
+		enriched.JobTitle = me.JobTitle
+		if enriched.groupOverage() {
+			groups, err := graphClient.Groups()
+			if err != nil && err != ErrGroupsForbidden {
+				err = ErrUnableToGetGraphUser
+			}
+			if err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(w, req.WithContext(ctx))
+				return
+			}
+			enriched.Groups = groups
+		}
+		ctx = WithUser(ctx, &enriched)
+		ctx = gologin.WithIdentity(ctx, newIdentity(&enriched))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// Photo returns the signed-in user's profile photo and its content type from
+// Microsoft Graph. The httpClient must be authorized with an access token for
+// Microsoft Graph (e.g. config.Client(ctx, token)). Returns ErrNoPhoto if the
+// user has no photo. If graphURL is empty, PublicGraph is used.
+func Photo(httpClient *http.Client, graphURL string) ([]byte, string, error) {
+	return newClient(httpClient, graphURL).Photo()
+}
+
+// validateResponse returns an error if the given Microsoft Graph user, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *graphUser, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetGraphUser
+	}
+	if user == nil || user.ID == "" {
+		return ErrUnableToGetGraphUser
+	}
+	return nil
+}
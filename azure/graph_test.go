@@ -0,0 +1,157 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+const testGraphUserJSON = `{"id": "b1f2c3d4", "displayName": "Ivy Crimson", "jobTitle": "Engineer"}`
+
+func TestGraphHandler(t *testing.T) {
+	proxyClient, server := newGraphTestServer(testGraphUserJSON)
+	defer server.Close()
+
+	cases := []struct {
+		user     *User
+		expected []string
+	}{
+		// groups claim present
+		{&User{ID: "54638001", Groups: []string{"claim-group"}}, []string{"claim-group"}},
+		// no groups claim, since the app does not emit group claims
+		{&User{ID: "54638001"}, nil},
+		// group overage
+		{&User{ID: "54638001", Groups: []string{}, ClaimNames: map[string]string{"groups": "src1"}}, []string{"group-1", "group-2"}},
+		{&User{ID: "54638001", HasGroups: true}, []string{"group-1", "group-2"}},
+	}
+	for _, c := range cases {
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+		ctx = WithUser(ctx, c.user)
+
+		success := func(w http.ResponseWriter, req *http.Request) {
+			user, err := UserFromContext(req.Context())
+			assert.Nil(t, err)
+			assert.Equal(t, "b1f2c3d4", user.ObjectID)
+			assert.Equal(t, "Engineer", user.JobTitle)
+			assert.Equal(t, c.expected, user.Groups)
+			identity, err := gologin.IdentityFromContext(req.Context())
+			assert.Nil(t, err)
+			assert.Equal(t, newIdentity(user), identity)
+			fmt.Fprintf(w, "success handler called")
+		}
+
+		// GraphHandler assert that:
+		// - the Graph user's object ID and job title are added to the User
+		// - groups are read from Graph on a group overage
+		// - the enriched User and its Identity are added to the success
+		//   handler ctx
+		handler := GraphHandler(&oauth2.Config{}, "", http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestGraphHandler_GroupsForbidden(t *testing.T) {
+	proxyClient, server := newGraphForbiddenTestServer(testGraphUserJSON)
+	defer server.Close()
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	// no groups claim, so groups are not read
+	success := func(w http.ResponseWriter, req *http.Request) {
+		user, err := UserFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "b1f2c3d4", user.ObjectID)
+		assert.Nil(t, user.Groups)
+		fmt.Fprintf(w, "success handler called")
+	}
+	handler := GraphHandler(&oauth2.Config{}, "", http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(WithUser(ctx, &User{ID: "54638001"})))
+	assert.Equal(t, "success handler called", w.Body.String())
+
+	// group overage, but the token may not read groups
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrGroupsForbidden, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// GraphHandler with only User.Read, assert that:
+	// - a 403 listing groups on overage calls the failure handler, since the
+	//   User's groups are unknown
+	handler = GraphHandler(&oauth2.Config{}, "", testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(WithUser(ctx, &User{ID: "54638001", HasGroups: true})))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestGraphHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Graph Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	ctx = WithUser(ctx, &User{ID: "54638001"})
+
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetGraphUser, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := GraphHandler(&oauth2.Config{}, "", testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestGraphHandler_MissingCtxUser(t *testing.T) {
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "any-token"})
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "azure: Context missing Azure Active Directory User", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := GraphHandler(&oauth2.Config{}, "", testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestPhoto(t *testing.T) {
+	proxyClient, server := newGraphTestServer(testGraphUserJSON)
+	defer server.Close()
+	photo, contentType, err := Photo(proxyClient, "")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("jpeg-bytes"), photo)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	errorClient, errorServer := testutils.NewErrorServer("Not Found", http.StatusNotFound)
+	defer errorServer.Close()
+	_, _, err = Photo(errorClient, "")
+	assert.Equal(t, ErrNoPhoto, err)
+}
+
+func TestAuthority_GraphURL(t *testing.T) {
+	assert.Equal(t, PublicGraph, Authority{}.GraphURL())
+	assert.Equal(t, USGovernmentGraph, Authority{Cloud: USGovernmentCloud}.GraphURL())
+	assert.Equal(t, ChinaGraph, Authority{Cloud: ChinaCloud}.GraphURL())
+}
@@ -9,18 +9,25 @@ import (
 
 	"github.com/dghubble/gologin"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
 	"github.com/dghubble/gologin/testutils"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2"
 )
 
 func TestAzureHandler(t *testing.T) {
-	// jsonData := `{"id": "54638001", "name": "Ivy Crimson"}`
-	expectedUser := &User{ID: "54638001", Name: "Ivy Crimson"}
-	anyToken := &oauth2.Token{AccessToken: "any-token"}
-	ctx := oauth2Login.WithToken(context.Background(), anyToken)
+	a := newAzureTestServer(t)
+	defer a.Close()
+	verifier, err := NewVerifier(context.Background(), Authority{Cloud: a.server.URL, Tenant: TenantOrganizations}, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := a.IDToken(t, a.Issuer(testTenantID), testTenantID, map[string]interface{}{"name": "Ivy Crimson", "oid": "b1f2c3d4", "roles": []string{"Admin"}})
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = oidc.WithNonce(ctx, testNonce)
 
-	config := &oauth2.Config{}
+	expectedUser := &User{ID: "54638001", Name: "Ivy Crimson", TenantID: testTenantID, ObjectID: "b1f2c3d4", Roles: []string{"Admin"}}
 	success := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		azureUser, err := UserFromContext(ctx)
@@ -30,36 +37,33 @@ func TestAzureHandler(t *testing.T) {
 	}
 	failure := testutils.AssertFailureNotCalled(t)
 
-	// AzureHandler assert that:
-	// - Token is read from the ctx and passed to the azure API
-	// - azure User is obtained from the azure API
+	// IDTokenHandler and azureHandler, assert that:
+	// - Token is read from the ctx and its ID Token is verified
+	// - azure User is obtained from the ID Token claims
 	// - success handler is called
 	// - azure User is added to the ctx of the success handler
-	provider, _ := NewProvider()
-	azureHandler := azureHandler(config, provider.Verifier(), http.HandlerFunc(success), failure)
+	azureHandler := oidc.IDTokenHandler(verifier, azureHandler(http.HandlerFunc(success), failure), failure)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	azureHandler.ServeHTTP(w, req.WithContext(ctx))
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
-func TestAzureHandler_MissingCtxToken(t *testing.T) {
-	config := &oauth2.Config{}
+func TestAzureHandler_MissingCtxIDToken(t *testing.T) {
 	success := testutils.AssertSuccessNotCalled(t)
 	failure := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 		err := gologin.ErrorFromContext(ctx)
 		if assert.NotNil(t, err) {
-			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+			assert.Equal(t, "oidc: Context missing ID Token", err.Error())
 		}
 		fmt.Fprintf(w, "failure handler called")
 	}
 
-	// AzureHandler called without Token in ctx, assert that:
+	// AzureHandler called without a verified ID Token in ctx, assert that:
 	// - failure handler is called
-	// - error about ctx missing token is added to the failure handler ctx
-	provider, _ := NewProvider()
-	azureHandler := azureHandler(config, provider.Verifier(), success, http.HandlerFunc(failure))
+	// - error about ctx missing ID Token is added to the failure handler ctx
+	azureHandler := azureHandler(success, http.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	azureHandler.ServeHTTP(w, req)
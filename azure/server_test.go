@@ -0,0 +1,121 @@
+package azure
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dghubble/gologin/internal/oidctest"
+	"github.com/dghubble/gologin/testutils"
+)
+
+const (
+	testClientID = "client_id"
+	testTenantID = "72f988bf-86f1-41af-91ab-2d7cd011db47"
+	testNonce    = "nonce_val"
+)
+
+// testAuthority is a mock Azure Active Directory cloud which serves tenant
+// discovery documents and signing keys and signs ID Tokens.
+type testAuthority struct {
+	server *httptest.Server
+	signer *oidctest.Signer
+}
+
+// newAzureTestServer returns a new testAuthority. Multi-tenant discovery
+// documents use an issuer template, like Azure. The caller must close the
+// server.
+func newAzureTestServer(t *testing.T) *testAuthority {
+	a := &testAuthority{signer: oidctest.NewSigner(t)}
+	mux := http.NewServeMux()
+	a.server = httptest.NewServer(mux)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		tenant := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/v2.0/.well-known/openid-configuration")
+		if tenant == r.URL.Path[1:] || strings.Contains(tenant, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		switch tenant {
+		case TenantCommon, TenantOrganizations, TenantConsumers:
+			tenant = tenantIDPlaceholder
+		case "contoso.onmicrosoft.com":
+			tenant = testTenantID
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":   a.Issuer(tenant),
+			"jwks_uri": a.server.URL + "/common/discovery/v2.0/keys",
+		})
+	})
+	mux.Handle("/common/discovery/v2.0/keys", a.signer.KeysHandler())
+	return a
+}
+
+// Issuer returns the issuer of the tenant.
+func (a *testAuthority) Issuer(tenantID string) string {
+	return a.server.URL + "/" + tenantID + "/v2.0"
+}
+
+// IDToken returns a signed ID Token for testNonce with the issuer, tenant ID,
+// and any extra claims.
+func (a *testAuthority) IDToken(t *testing.T, issuer, tenantID string, extra map[string]interface{}) string {
+	claims := map[string]interface{}{
+		"iss":   issuer,
+		"aud":   testClientID,
+		"sub":   "54638001",
+		"tid":   tenantID,
+		"nonce": testNonce,
+	}
+	return a.signer.IDToken(t, claims, extra)
+}
+
+// Close closes the underlying server.
+func (a *testAuthority) Close() {
+	a.server.Close()
+}
+
+// newGraphTestServer returns a new httptest.Server which mocks the Microsoft
+// Graph /me, /me/memberOf (in two pages), and /me/photo endpoints and a
+// client which proxies requests to the server. The caller must close the
+// server.
+func newGraphTestServer(meJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1.0/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, meJSON)
+	})
+	mux.HandleFunc("/v1.0/me/memberOf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("$skiptoken") == "" {
+			fmt.Fprint(w, `{"value": [{"@odata.type": "#microsoft.graph.group", "id": "group-1"}, {"@odata.type": "#microsoft.graph.directoryRole", "id": "role-1"}], "@odata.nextLink": "https://graph.microsoft.com/v1.0/me/memberOf?$skiptoken=page2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"value": [{"@odata.type": "#microsoft.graph.group", "id": "group-2"}]}`)
+	})
+	mux.HandleFunc("/v1.0/me/photo/$value", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		fmt.Fprint(w, "jpeg-bytes")
+	})
+	return client, server
+}
+
+// newGraphForbiddenTestServer returns a new httptest.Server which mocks the
+// Microsoft Graph /me endpoint and a /me/memberOf endpoint which forbids a
+// token without GroupMember.Read.All, and a client which proxies requests to
+// the server. The caller must close the server.
+func newGraphForbiddenTestServer(meJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v1.0/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, meJSON)
+	})
+	mux.HandleFunc("/v1.0/me/memberOf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error": {"code": "Authorization_RequestDenied", "message": "Insufficient privileges to complete the operation."}}`)
+	})
+	return client, server
+}
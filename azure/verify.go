@@ -1,10 +1,24 @@
 package azure
 
-const azureAPI = "https://graph.azure.com/v2.8/"
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/sling"
+)
+
+// Microsoft Graph endpoints of the Azure cloud instances.
+const (
+	PublicGraph       = "https://graph.microsoft.com"
+	USGovernmentGraph = "https://graph.microsoft.us"
+	ChinaGraph        = "https://microsoftgraph.chinacloudapi.cn"
+)
 
 // User is a Azure user.
 //
-// Note that user ids are unique to each app.
+// Note that user ids are unique to each app, while object ids are unique
+// across apps in a tenant.
 // ref: https://docs.microsoft.com/en-us/azure/active-directory/active-directory-v2-tokens#id-tokens
 type User struct {
 	TenantID          string `json:"tid"`
@@ -12,5 +26,130 @@ type User struct {
 	Email             string `json:"email"`
 	PreferredUsername string `json:"preferred_username"`
 	ID                string `json:"sub"`
-	OrganizationID    int    `json:"oid"`
+	ObjectID          string `json:"oid"`
+	// Groups are the object IDs of the user's groups, if the app emits group
+	// claims or GraphHandler enriched the User.
+	Groups []string `json:"groups"`
+	// Roles are the user's app roles.
+	Roles []string `json:"roles"`
+	// JobTitle is set by GraphHandler.
+	JobTitle string `json:"jobTitle"`
+	// HasGroups and ClaimNames indicate the user's groups were too many to
+	// include in the ID Token (group overage).
+	HasGroups  bool              `json:"hasgroups"`
+	ClaimNames map[string]string `json:"_claim_names"`
+}
+
+// groupOverage returns true if the ID Token omitted the user's groups because
+// there were too many.
+func (u *User) groupOverage() bool {
+	_, ok := u.ClaimNames["groups"]
+	return ok || u.HasGroups
+}
+
+// graphUser is a Microsoft Graph user.
+//
+// ref: https://docs.microsoft.com/en-us/graph/api/user-get
+type graphUser struct {
+	ID                string `json:"id"`
+	DisplayName       string `json:"displayName"`
+	Mail              string `json:"mail"`
+	UserPrincipalName string `json:"userPrincipalName"`
+	JobTitle          string `json:"jobTitle"`
+}
+
+// directoryObjects is a page of Microsoft Graph directory objects.
+type directoryObjects struct {
+	Values []struct {
+		Type string `json:"@odata.type"`
+		ID   string `json:"id"`
+	} `json:"value"`
+	NextLink string `json:"@odata.nextLink"`
+}
+
+// graphError is a Microsoft Graph error response.
+type graphError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// client is a Microsoft Graph client for the signed-in user.
+type client struct {
+	c     *http.Client
+	sling *sling.Sling
+}
+
+func newClient(httpClient *http.Client, graphURL string) *client {
+	if graphURL == "" {
+		graphURL = PublicGraph
+	}
+	base := sling.New().Client(httpClient).Base(strings.TrimSuffix(graphURL, "/") + "/v1.0/")
+	return &client{
+		c:     httpClient,
+		sling: base,
+	}
+}
+
+// Me gets the signed-in user's profile.
+func (c *client) Me() (*graphUser, *http.Response, error) {
+	user := new(graphUser)
+	resp, err := c.sling.New().Get("me").Receive(user, new(graphError))
+	return user, resp, err
+}
+
+// Groups gets the object IDs of the groups the signed-in user is a direct
+// member of, following result pages. Returns ErrGroupsForbidden if the token
+// lacks permission to list them.
+func (c *client) Groups() ([]string, error) {
+	groups := []string{}
+	req := c.sling.New().Get("me/memberOf")
+	for {
+		page := new(directoryObjects)
+		resp, err := req.Receive(page, new(graphError))
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusForbidden {
+			return nil, ErrGroupsForbidden
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, ErrUnableToGetGraphUser
+		}
+		for _, object := range page.Values {
+			if object.Type == "#microsoft.graph.group" {
+				groups = append(groups, object.ID)
+			}
+		}
+		if page.NextLink == "" {
+			return groups, nil
+		}
+		// next links are absolute URLs
+		req = c.sling.New().Get(page.NextLink)
+	}
+}
+
+// Photo gets the signed-in user's profile photo and its content type.
+func (c *client) Photo() ([]byte, string, error) {
+	req, err := c.sling.New().Get("me/photo/$value").Request()
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", ErrNoPhoto
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", ErrUnableToGetPhoto
+	}
+	photo, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return photo, resp.Header.Get("Content-Type"), nil
 }
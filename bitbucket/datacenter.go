@@ -0,0 +1,88 @@
+package bitbucket
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// dataCenterAuthorizePath is the path of the authorization endpoint under a
+// Bitbucket Data Center instance's base URL.
+const dataCenterAuthorizePath = "/rest/oauth2/latest/authorize"
+
+// DataCenterEndpoint returns the OAuth2 Endpoint of the Bitbucket Data Center
+// (or Server) instance at the base URL (e.g. "https://bitbucket.example.com").
+func DataCenterEndpoint(baseURL string) oauth2.Endpoint {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return oauth2.Endpoint{
+		AuthURL:  baseURL + dataCenterAuthorizePath,
+		TokenURL: baseURL + "/rest/oauth2/latest/token",
+	}
+}
+
+// DataCenterProviderName returns the name of the Bitbucket Data Center
+// instance serving the Endpoint, used as the Identity Provider and Provider
+// route name. The name is "bitbucket-{host}" (e.g.
+// "bitbucket-bitbucket.example.com"), since user IDs are only unique within
+// an instance.
+func DataCenterProviderName(endpoint oauth2.Endpoint) string {
+	return internal.InstanceName("bitbucket", dataCenterBaseURL(endpoint))
+}
+
+// dataCenterBaseURL returns the base URL of the Bitbucket Data Center
+// instance serving the Endpoint.
+func dataCenterBaseURL(endpoint oauth2.Endpoint) string {
+	return strings.TrimSuffix(endpoint.AuthURL, dataCenterAuthorizePath)
+}
+
+// DataCenterCallbackHandler handles Bitbucket Data Center redirection URI
+// requests and adds the access token and User to the ctx. If authentication
+// succeeds, handling delegates to the success handler, otherwise to the
+// failure handler.
+//
+// The User is fetched from the instance which issued the token, as
+// determined by the config Endpoint (see DataCenterEndpoint), and its
+// Identity Provider is the instance's DataCenterProviderName. Request the
+// "PUBLIC_REPOS" scope (or higher) to read the user.
+func DataCenterCallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = dataCenterHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// dataCenterHandler is a http.Handler that gets the OAuth2 Token from the ctx
+// to get the corresponding Bitbucket Data Center User. If successful, the
+// User is added to the ctx and the success handler is called. Otherwise, the
+// failure handler is called.
+func dataCenterHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	baseURL := dataCenterBaseURL(config.Endpoint)
+	name := DataCenterProviderName(config.Endpoint)
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		bitbucketClient := newDataCenterClient(httpClient, baseURL)
+		user, resp, err := bitbucketClient.CurrentUser()
+		err = validateResponse(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(name, user))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
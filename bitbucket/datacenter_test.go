@@ -0,0 +1,127 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestDataCenterEndpoint(t *testing.T) {
+	expected := oauth2.Endpoint{
+		AuthURL:  "https://bitbucket.example.com/rest/oauth2/latest/authorize",
+		TokenURL: "https://bitbucket.example.com/rest/oauth2/latest/token",
+	}
+	assert.Equal(t, expected, DataCenterEndpoint("https://bitbucket.example.com/"))
+}
+
+func TestDataCenterProviderName(t *testing.T) {
+	assert.Equal(t, "bitbucket-bitbucket.example.com", DataCenterProviderName(DataCenterEndpoint("https://bitbucket.example.com/")))
+	assert.Equal(t, "bitbucket-example.com-7990-bitbucket", DataCenterProviderName(DataCenterEndpoint("https://example.com:7990/bitbucket")))
+	// instances are distinct from bitbucket.org and from each other
+	assert.NotEqual(t, "bitbucket", DataCenterProviderName(DataCenterEndpoint("https://bitbucket.example.com")))
+	assert.NotEqual(t, DataCenterProviderName(DataCenterEndpoint("https://a.example.com")), DataCenterProviderName(DataCenterEndpoint("https://b.example.com")))
+}
+
+func TestDataCenterProvider(t *testing.T) {
+	config := &oauth2.Config{Endpoint: DataCenterEndpoint("https://bitbucket.example.com")}
+	provider := DataCenterProvider(config)
+	assert.Equal(t, "bitbucket-bitbucket.example.com", provider.Name())
+
+	// Provider LoginHandler assert that:
+	// - the request is redirected to the instance's AuthURL
+	mux := http.NewServeMux()
+	gologin.NewRegistry(Provider(&oauth2.Config{}), provider).Mount(mux, gologin.DebugOnlyCookieConfig, testutils.AssertSuccessNotCalled(t), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/bitbucket-bitbucket.example.com/login", nil)
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Contains(t, w.HeaderMap.Get("Location"), "https://bitbucket.example.com/rest/oauth2/latest/authorize")
+}
+
+func TestDataCenterHandler(t *testing.T) {
+	jsonData := `{"id": 101, "name": "bitster@corp", "slug": "bitster_corp", "displayName": "Atlas Ian", "emailAddress": "atlas@example.com", "links": {"self": [{"href": "https://example.com/bitbucket/users/bitster_corp"}]}}`
+	expectedUser := &User{ID: 101, Username: "bitster@corp", DisplayName: "Atlas Ian", Email: "atlas@example.com"}
+	expectedUser.Links.HTML.Href = "https://example.com/bitbucket/users/bitster_corp"
+	proxyClient, server := newDataCenterTestServer("bitster@corp", jsonData)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{Endpoint: DataCenterEndpoint("https://example.com/bitbucket")}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		bitbucketUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, bitbucketUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "bitbucket-example.com-bitbucket", Subject: "101", Email: "atlas@example.com", Name: "Atlas Ian", Username: "bitster@corp"}, identity)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// dataCenterHandler assert that:
+	// - the user's username is read from whoami under the instance context path
+	// - bitbucket User is the user whose name matches the username, even if
+	//   its slug differs
+	// - success handler is called with the User in the ctx
+	handler := dataCenterHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestDataCenterHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Bitbucket Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{Endpoint: DataCenterEndpoint("https://bitbucket.example.com")}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetBitbucketUser, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := dataCenterHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestDataCenterHandler_UserNotFound(t *testing.T) {
+	jsonData := `{"id": 103, "name": "bitster@corp.example", "slug": "bitster_corp.example"}`
+	proxyClient, server := newDataCenterTestServer("bitster@corp", jsonData)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{Endpoint: DataCenterEndpoint("https://example.com/bitbucket")}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetBitbucketUser, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// dataCenterHandler with no user named exactly the username, assert that:
+	// - failure handler is called, rather than a partial match's success
+	handler := dataCenterHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
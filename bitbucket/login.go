@@ -3,6 +3,7 @@ package bitbucket
 import (
 	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/dghubble/gologin"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
@@ -11,7 +12,8 @@ import (
 
 // Bitbucket login errors
 var (
-	ErrUnableToGetBitbucketUser = errors.New("bitbucket: unable to get Bitbucket User")
+	ErrUnableToGetBitbucketUser   = errors.New("bitbucket: unable to get Bitbucket User")
+	ErrUnableToGetBitbucketEmails = errors.New("bitbucket: unable to get Bitbucket User emails")
 )
 
 // CSRFHandler checks for a state cookie. If found, the state value is read
@@ -66,7 +68,14 @@ func bitbucketHandler(config *oauth2.Config, success, failure http.Handler) http
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		user.Email, user.IsEmailConfirmed, err = bitbucketClient.PrimaryEmail()
+		if err != nil {
+			ctx = gologin.WithError(ctx, ErrUnableToGetBitbucketEmails)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity("bitbucket", user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -83,3 +92,21 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Bitbucket User of the named
+// provider.
+func newIdentity(name string, user *User) *gologin.Identity {
+	subject := user.UUID
+	if subject == "" && user.ID != 0 {
+		subject = strconv.FormatInt(user.ID, 10)
+	}
+	return &gologin.Identity{
+		Provider:      name,
+		Subject:       subject,
+		Email:         user.Email,
+		EmailVerified: user.IsEmailConfirmed,
+		Name:          user.DisplayName,
+		Username:      user.Username,
+		AvatarURL:     user.Links.Avatar.Href,
+	}
+}
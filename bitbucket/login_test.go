@@ -17,7 +17,7 @@ import (
 func TestBitbucketHandler(t *testing.T) {
 	jsonData := `{"username": "bitster", "display_name": "Atlas Ian"}`
 	expectedUser := &User{Username: "bitster", DisplayName: "Atlas Ian"}
-	proxyClient, server := newBitbucketTestServer(jsonData)
+	proxyClient, server := newBitbucketTestServer(jsonData, `{"values": []}`)
 	defer server.Close()
 	// oauth2 Client will use the proxy client's base Transport
 	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
@@ -30,6 +30,9 @@ func TestBitbucketHandler(t *testing.T) {
 		bitbucketUser, err := UserFromContext(ctx)
 		assert.Nil(t, err)
 		assert.Equal(t, expectedUser, bitbucketUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "bitbucket", Name: "Atlas Ian", Username: "bitster"}, identity)
 		fmt.Fprintf(w, "success handler called")
 	}
 	failure := testutils.AssertFailureNotCalled(t)
@@ -97,6 +100,100 @@ func TestBitbucketHandler_ErrorGettingUser(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestBitbucketHandler_Email(t *testing.T) {
+	jsonData := `{"uuid": "{b2a9}", "username": "bitster", "display_name": "Atlas Ian"}`
+	emailsJSON := `{"values": [{"email": "old@example.com", "is_primary": false, "is_confirmed": true}, {"email": "atlas@example.com", "is_primary": true, "is_confirmed": true}]}`
+	proxyClient, server := newBitbucketTestServer(jsonData, emailsJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		bitbucketUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "atlas@example.com", bitbucketUser.Email)
+		assert.True(t, bitbucketUser.IsEmailConfirmed)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "{b2a9}", identity.Subject)
+		assert.True(t, identity.EmailVerified)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	bitbucketHandler := bitbucketHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	bitbucketHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestBitbucketHandler_ErrorGettingEmails(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/api/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"username": "bitster"}`)
+	})
+	mux.HandleFunc("/api/2.0/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"type": "error"}`, http.StatusInternalServerError)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetBitbucketEmails, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// BitbucketHandler cannot get emails, assert that:
+	// - failure handler is called with the error, instead of dropping it
+	bitbucketHandler := bitbucketHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	bitbucketHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestBitbucketHandler_EmailsForbidden(t *testing.T) {
+	client, mux, server := testutils.TestServer()
+	defer server.Close()
+	mux.HandleFunc("/api/2.0/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"uuid": "{b2a9}", "username": "bitster"}`)
+	})
+	mux.HandleFunc("/api/2.0/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		// token without the email scope
+		http.Error(w, `{"type": "error"}`, http.StatusForbidden)
+	})
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		bitbucketUser, err := UserFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "bitster", bitbucketUser.Username)
+		assert.Equal(t, "", bitbucketUser.Email)
+		assert.False(t, bitbucketUser.IsEmailConfirmed)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// BitbucketHandler may not list emails, assert that:
+	// - success handler is called with a User without an email
+	bitbucketHandler := bitbucketHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	bitbucketHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
 func TestValidateResponse(t *testing.T) {
 	validUser := &User{Username: "bitster"}
 	validResponse := &http.Response{StatusCode: 200}
@@ -0,0 +1,54 @@
+package bitbucket
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "bitbucket" which performs Bitbucket
+// web logins with the given config, for mounting with a gologin.Registry.
+func Provider(config *oauth2.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+type provider struct {
+	config *oauth2.Config
+}
+
+func (p *provider) Name() string {
+	return "bitbucket"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, CallbackHandler(p.config, success, failure))
+}
+
+// DataCenterProvider returns a gologin.Provider which performs Bitbucket Data
+// Center web logins with the given config, for mounting with a
+// gologin.Registry. It is named after the instance of the config Endpoint
+// (see DataCenterProviderName).
+func DataCenterProvider(config *oauth2.Config) gologin.Provider {
+	return &dataCenterProvider{config: config}
+}
+
+type dataCenterProvider struct {
+	config *oauth2.Config
+}
+
+func (p *dataCenterProvider) Name() string {
+	return DataCenterProviderName(p.config.Endpoint)
+}
+
+func (p *dataCenterProvider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *dataCenterProvider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, DataCenterCallbackHandler(p.config, success, failure))
+}
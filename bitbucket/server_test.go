@@ -9,14 +9,61 @@ import (
 )
 
 // newBitbucketTestServer returns a new httptest.Server which mocks the
-// Bitbucket user endpoint and a client which proxies requests to the server.
-// The server responds with the given json data. The caller must close the
-// server.
-func newBitbucketTestServer(jsonData string) (*http.Client, *httptest.Server) {
+// Bitbucket user and user emails endpoints and a client which proxies
+// requests to the server. The server responds with the given json data. The
+// caller must close the server.
+func newBitbucketTestServer(jsonData, emailsJSON string) (*http.Client, *httptest.Server) {
 	client, mux, server := testutils.TestServer()
 	mux.HandleFunc("/api/2.0/user", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprintf(w, jsonData)
 	})
+	mux.HandleFunc("/api/2.0/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, emailsJSON)
+	})
+	return client, server
+}
+
+// newWorkspacesTestServer returns a new httptest.Server which mocks the
+// Bitbucket workspace permissions endpoint (in two pages) and a client which
+// proxies requests to the server. The caller must close the server.
+func newWorkspacesTestServer() (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/api/2.0/user/permissions/workspaces", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			fmt.Fprint(w, `{"values": [{"permission": "member", "workspace": {"slug": "acme"}}], "next": "https://bitbucket.org/api/2.0/user/permissions/workspaces?page=2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"values": [{"permission": "owner", "workspace": {"slug": "initech"}}]}`)
+	})
+	return client, server
+}
+
+// newDataCenterTestServer returns a new httptest.Server which mocks the
+// Bitbucket Data Center whoami and users endpoints under a context path and a
+// client which proxies requests to the server. Whoami responds with the
+// username and users filtered by it are listed in two pages, the second
+// ending with the given user json data. The caller must close the server.
+func newDataCenterTestServer(username, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/bitbucket/plugins/servlet/applinks/whoami", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, username)
+	})
+	mux.HandleFunc("/bitbucket/rest/api/latest/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("filter") != username {
+			fmt.Fprint(w, `{"values": [], "isLastPage": true}`)
+			return
+		}
+		if r.URL.Query().Get("start") == "" {
+			// filters also match usernames containing the username
+			fmt.Fprintf(w, `{"values": [{"id": 102, "name": "%s2", "slug": "other"}], "isLastPage": false, "nextPageStart": 1}`, username)
+			return
+		}
+		fmt.Fprintf(w, `{"values": [%s], "isLastPage": true}`, jsonData)
+	})
 	return client, server
 }
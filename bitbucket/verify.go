@@ -1,7 +1,10 @@
 package bitbucket
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/dghubble/sling"
 )
@@ -10,7 +13,10 @@ const bitbucketAPI = "https://bitbucket.org/api/2.0/"
 
 // User is a Bitbucket user.
 type User struct {
-	UUID        string `json:"uuid"`
+	UUID string `json:"uuid"`
+	// ID is the user's ID on Bitbucket Data Center. Bitbucket Cloud users
+	// are identified by UUID instead.
+	ID          int64  `json:"id"`
 	Username    string `json:"username"`
 	DisplayName string `json:"display_name"`
 	Links       struct {
@@ -26,6 +32,7 @@ type User struct {
 	IsEmailConfirmed bool   `json:"is_confirmed"`
 }
 
+// UserEmails is a page of a Bitbucket user's emails.
 type UserEmails struct {
 	Values []struct {
 		Email       string `json:"email"`
@@ -36,13 +43,15 @@ type UserEmails struct {
 
 // client is a Bitbucket client for obtaining a User.
 type client struct {
+	c     *http.Client
 	sling *sling.Sling
 }
 
-// newClient returns a new Bitbucket client.
+// newClient returns a new Bitbucket Cloud client.
 func newClient(httpClient *http.Client) *client {
 	base := sling.New().Client(httpClient).Base(bitbucketAPI)
 	return &client{
+		c:     httpClient,
 		sling: base,
 	}
 }
@@ -52,16 +61,168 @@ func newClient(httpClient *http.Client) *client {
 func (c *client) CurrentUser() (*User, *http.Response, error) {
 	user := new(User)
 	resp, err := c.sling.New().Get("user").ReceiveSuccess(user)
-	if err == nil {
-		emails := new(UserEmails)
-		_, err = c.sling.New().Get("user/emails").ReceiveSuccess(emails)
-		for _, email := range emails.Values {
-			if email.IsPrimary {
-				user.Email = email.Email
-				user.IsEmailConfirmed = email.IsConfirmed
-				break
+	return user, resp, err
+}
+
+// PrimaryEmail gets the current user's primary email and whether it is
+// confirmed. Requires the "email" scope. Tokens without it are forbidden from
+// listing emails, so the user is treated as having no email.
+func (c *client) PrimaryEmail() (string, bool, error) {
+	emails := new(UserEmails)
+	resp, err := c.sling.New().Get("user/emails").ReceiveSuccess(emails)
+	if err != nil {
+		return "", false, err
+	}
+	if resp.StatusCode == http.StatusForbidden {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, ErrUnableToGetBitbucketEmails
+	}
+	for _, email := range emails.Values {
+		if email.IsPrimary {
+			return email.Email, email.IsConfirmed, nil
+		}
+	}
+	return "", false, nil
+}
+
+// workspacePermissions is a page of the current user's workspace
+// memberships.
+type workspacePermissions struct {
+	Values []struct {
+		Permission string `json:"permission"`
+		Workspace  struct {
+			Slug string `json:"slug"`
+		} `json:"workspace"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// Workspaces gets the slugs of the current user's workspaces, following
+// result pages.
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-workspaces/#api-user-permissions-workspaces-get
+func (c *client) Workspaces() ([]string, error) {
+	workspaces := []string{}
+	req := c.sling.New().Get("user/permissions/workspaces")
+	for {
+		page := new(workspacePermissions)
+		resp, err := req.ReceiveSuccess(page)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, ErrUnableToGetWorkspaces
+		}
+		for _, permission := range page.Values {
+			workspaces = append(workspaces, permission.Workspace.Slug)
+		}
+		if page.Next == "" {
+			return workspaces, nil
+		}
+		// next links are absolute URLs
+		req = c.sling.New().Get(page.Next)
+	}
+}
+
+// dataCenterUser is a Bitbucket Data Center user.
+// https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-system-maintenance/#api-api-latest-users-userslug-get
+type dataCenterUser struct {
+	ID           int64  `json:"id"`
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	DisplayName  string `json:"displayName"`
+	EmailAddress string `json:"emailAddress"`
+	Links        struct {
+		Self []struct {
+			Href string `json:"href"`
+		} `json:"self"`
+	} `json:"links"`
+}
+
+// dataCenterUsers is a page of Bitbucket Data Center users.
+// https://developer.atlassian.com/server/bitbucket/rest/v811/api-group-system-maintenance/#api-api-latest-users-get
+type dataCenterUsers struct {
+	Values        []dataCenterUser `json:"values"`
+	IsLastPage    bool             `json:"isLastPage"`
+	NextPageStart int              `json:"nextPageStart"`
+}
+
+// dataCenterClient is a Bitbucket Data Center client for obtaining a User.
+type dataCenterClient struct {
+	c     *http.Client
+	sling *sling.Sling
+}
+
+// newDataCenterClient returns a new client of the Bitbucket Data Center
+// instance at the base URL.
+func newDataCenterClient(httpClient *http.Client, baseURL string) *dataCenterClient {
+	base := sling.New().Client(httpClient).Base(strings.TrimSuffix(baseURL, "/") + "/")
+	return &dataCenterClient{
+		c:     httpClient,
+		sling: base,
+	}
+}
+
+// CurrentUser gets the current user's profile information. Data Center has
+// no current user endpoint, so the user's username is read from whoami first
+// and then found among the users it filters. Usernames may differ from user
+// slugs, so the user is matched by name rather than fetched by slug.
+func (c *dataCenterClient) CurrentUser() (*User, *http.Response, error) {
+	req, err := c.sling.New().Get("plugins/servlet/applinks/whoami").Request()
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := c.c.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, resp, nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return nil, resp, err
+	}
+	username := strings.TrimSpace(string(body))
+	params := &dataCenterUsersParams{Filter: username}
+	for {
+		page := new(dataCenterUsers)
+		resp, err = c.sling.New().Get("rest/api/latest/users").QueryStruct(params).ReceiveSuccess(page)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return nil, resp, err
+		}
+		for _, dcUser := range page.Values {
+			// usernames are case-insensitive
+			if strings.EqualFold(dcUser.Name, username) {
+				return dcUser.user(), resp, nil
 			}
 		}
+		if page.IsLastPage {
+			return nil, resp, nil
+		}
+		params.Start = page.NextPageStart
 	}
-	return user, resp, err
+}
+
+// dataCenterUsersParams are the query parameters of Data Center's users
+// endpoint.
+type dataCenterUsersParams struct {
+	Filter string `url:"filter"`
+	Start  int    `url:"start,omitempty"`
+}
+
+// user returns the User of the Data Center user.
+func (u *dataCenterUser) user() *User {
+	user := &User{
+		ID:          u.ID,
+		Username:    u.Name,
+		DisplayName: u.DisplayName,
+		Email:       u.EmailAddress,
+	}
+	if len(u.Links.Self) > 0 {
+		user.Links.HTML.Href = u.Links.Self[0].Href
+	}
+	return user
 }
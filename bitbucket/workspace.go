@@ -0,0 +1,83 @@
+package bitbucket
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnableToGetWorkspaces is returned when a user's workspaces cannot be
+// listed.
+var ErrUnableToGetWorkspaces = errors.New("bitbucket: unable to get Bitbucket workspaces")
+
+// WorkspaceError is returned when a Bitbucket user is not a member of an
+// allowed workspace.
+type WorkspaceError struct {
+	// Username is the user's username.
+	Username string
+}
+
+func (e *WorkspaceError) Error() string {
+	return fmt.Sprintf("bitbucket: %s is not a member of an allowed workspace", e.Username)
+}
+
+// WorkspaceHandler is a http.Handler that lists the Bitbucket Cloud
+// workspaces of the Bitbucket User in the ctx and checks that one of them is
+// an allowed workspace slug. Chain it as the success handler of
+// CallbackHandler. If the user is a member, the success handler is called.
+// Otherwise, the failure handler is called with a *WorkspaceError. If no
+// workspaces are allowed, no user may log in.
+//
+// The access token needs the "account" scope to list workspaces.
+func WorkspaceHandler(config *oauth2.Config, workspaces []string, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		bitbucketClient := newClient(config.Client(ctx, token))
+		member, err := bitbucketClient.Workspaces()
+		if err != nil {
+			ctx = gologin.WithError(ctx, ErrUnableToGetWorkspaces)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if !containsFold(workspaces, member) {
+			ctx = gologin.WithError(ctx, &WorkspaceError{Username: user.Username})
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// containsFold returns true if any value is in allowed, ignoring case since
+// workspace slugs are lowercase.
+func containsFold(allowed, values []string) bool {
+	for _, a := range allowed {
+		for _, v := range values {
+			if strings.EqualFold(a, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package bitbucket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestWorkspaceHandler(t *testing.T) {
+	proxyClient, server := newWorkspacesTestServer()
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	ctx = WithUser(ctx, &User{Username: "bitster"})
+
+	config := &oauth2.Config{}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// WorkspaceHandler with a member of a workspace on the second page, assert
+	// that:
+	// - all pages of workspaces are listed
+	// - success handler is called
+	handler := WorkspaceHandler(config, []string{"Initech"}, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestWorkspaceHandler_NotMember(t *testing.T) {
+	proxyClient, server := newWorkspacesTestServer()
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	ctx = WithUser(ctx, &User{Username: "bitster"})
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, &WorkspaceError{Username: "bitster"}, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// no workspaces allowed rejects every user
+	for _, workspaces := range [][]string{{"umbrella"}, nil} {
+		handler := WorkspaceHandler(config, workspaces, success, http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestWorkspaceHandler_ErrorGettingWorkspaces(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Bitbucket Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	ctx = WithUser(ctx, &User{Username: "bitster"})
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetWorkspaces, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := WorkspaceHandler(config, []string{"acme"}, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestWorkspaceError(t *testing.T) {
+	err := &WorkspaceError{Username: "bitster"}
+	assert.Equal(t, "bitbucket: bitster is not a member of an allowed workspace", err.Error())
+}
@@ -21,6 +21,15 @@ type CookieConfig struct {
 	// Secure flag indicating to the browser that the cookie should only be
 	// transmitted over a TLS HTTPS connection. Recommended true in production.
 	Secure bool
+	// Keyring signs cookie values along with the time they were issued, so
+	// tampered or expired (older than MaxAge) cookies are rejected. Replicas
+	// sharing a Keyring can verify each other's cookies. Recommended in
+	// production. Values are stored in plaintext if nil.
+	Keyring *Keyring
+	// DisablePKCE stops OAuth2 CSRF handlers from issuing a PKCE code verifier
+	// cookie, so no code challenge or verifier is sent. Only set it for
+	// providers which reject PKCE parameters.
+	DisablePKCE bool
 }
 
 // DefaultCookieConfig configures short-lived temporary http.Cookie creation.
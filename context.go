@@ -10,6 +10,7 @@ type key int
 
 const (
 	errorKey key = iota
+	identityKey
 )
 
 // WithError returns a copy of ctx that stores the given error value.
@@ -26,3 +27,17 @@ func ErrorFromContext(ctx context.Context) error {
 	}
 	return err
 }
+
+// WithIdentity returns a copy of ctx that stores the Identity.
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// IdentityFromContext returns the Identity from the ctx.
+func IdentityFromContext(ctx context.Context) (*Identity, error) {
+	identity, ok := ctx.Value(identityKey).(*Identity)
+	if !ok {
+		return nil, fmt.Errorf("Context missing Identity")
+	}
+	return identity, nil
+}
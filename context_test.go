@@ -21,3 +21,19 @@ func TestErrorFromContext_Error(t *testing.T) {
 		assert.Equal(t, "Context missing error value", err.Error())
 	}
 }
+
+func TestContextIdentity(t *testing.T) {
+	expectedIdentity := &Identity{Provider: "github", Subject: "917408"}
+	ctx := WithIdentity(context.Background(), expectedIdentity)
+	identity, err := IdentityFromContext(ctx)
+	assert.Equal(t, expectedIdentity, identity)
+	assert.Nil(t, err)
+}
+
+func TestIdentityFromContext_Error(t *testing.T) {
+	identity, err := IdentityFromContext(context.Background())
+	assert.Nil(t, identity)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "Context missing Identity", err.Error())
+	}
+}
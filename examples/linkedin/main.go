@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -12,7 +13,6 @@ import (
 	"github.com/dghubble/gologin/linkedin"
 	"github.com/dghubble/sessions"
 	"golang.org/x/oauth2"
-	linkedinOAuth2 "golang.org/x/oauth2/linkedin"
 )
 
 const (
@@ -31,7 +31,11 @@ type Config struct {
 }
 
 // New returns a new ServeMux with app routes.
-func New(config *Config) *http.ServeMux {
+func New(config *Config) (*http.ServeMux, error) {
+	verifier, err := linkedin.NewVerifier(context.Background(), config.LinkedinClientID)
+	if err != nil {
+		return nil, err
+	}
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", welcomeHandler)
 	mux.Handle("/profile", requireLogin(http.HandlerFunc(profileHandler)))
@@ -41,14 +45,14 @@ func New(config *Config) *http.ServeMux {
 		ClientID:     config.LinkedinClientID,
 		ClientSecret: config.LinkedinClientSecret,
 		RedirectURL:  "http://localhost:8080/linkedin/callback",
-		Endpoint:     linkedinOAuth2.Endpoint,
-		Scopes:       []string{"r_basicprofile", "r_emailaddress"},
+		Endpoint:     linkedin.Endpoint,
+		Scopes:       []string{"openid", "profile", "email"},
 	}
 	// state param cookies require HTTPS by default; disable for localhost development
 	stateConfig := gologin.DebugOnlyCookieConfig
 	mux.Handle("/linkedin/login", linkedin.CSRFHandler(stateConfig, linkedin.LoginHandler(oauth2Config, nil)))
-	mux.Handle("/linkedin/callback", linkedin.CSRFHandler(stateConfig, linkedin.CallbackHandler(oauth2Config, issueSession(), nil)))
-	return mux
+	mux.Handle("/linkedin/callback", linkedin.CSRFHandler(stateConfig, linkedin.CallbackHandler(oauth2Config, verifier, issueSession(), nil)))
+	return mux, nil
 }
 
 // issueSession issues a cookie session after successful Linkedin login
@@ -141,8 +145,12 @@ func main() {
 		log.Fatal("Missing Linkedin Client Secret")
 	}
 
+	mux, err := New(config)
+	if err != nil {
+		log.Fatal(err)
+	}
 	log.Printf("Starting Server listening on %s\n", address)
-	err := http.ListenAndServe(address, New(config))
+	err = http.ListenAndServe(address, mux)
 	if err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
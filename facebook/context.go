@@ -10,6 +10,8 @@ type key int
 
 const (
 	userKey key = iota
+	userIDKey
+	confirmationCodeKey
 )
 
 // WithUser returns a copy of ctx that stores the Facebook User.
@@ -25,3 +27,35 @@ func UserFromContext(ctx context.Context) (*User, error) {
 	}
 	return user, nil
 }
+
+// WithUserID returns a copy of ctx that stores the Facebook user ID of a
+// signed request.
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserIDFromContext returns the Facebook user ID of a signed request from the
+// ctx.
+func UserIDFromContext(ctx context.Context) (string, error) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	if !ok {
+		return "", fmt.Errorf("facebook: Context missing Facebook user ID")
+	}
+	return userID, nil
+}
+
+// WithConfirmationCode returns a copy of ctx that stores the confirmation
+// code of a data deletion request.
+func WithConfirmationCode(ctx context.Context, code string) context.Context {
+	return context.WithValue(ctx, confirmationCodeKey, code)
+}
+
+// ConfirmationCodeFromContext returns the confirmation code of a data
+// deletion request from the ctx.
+func ConfirmationCodeFromContext(ctx context.Context) (string, error) {
+	code, ok := ctx.Value(confirmationCodeKey).(string)
+	if !ok {
+		return "", fmt.Errorf("facebook: Context missing confirmation code")
+	}
+	return code, nil
+}
@@ -22,3 +22,33 @@ func TestContextUser_Error(t *testing.T) {
 		assert.Equal(t, "facebook: Context missing Facebook User", err.Error())
 	}
 }
+
+func TestContextUserID(t *testing.T) {
+	ctx := WithUserID(context.Background(), "54638001")
+	userID, err := UserIDFromContext(ctx)
+	assert.Equal(t, "54638001", userID)
+	assert.Nil(t, err)
+}
+
+func TestContextUserID_Error(t *testing.T) {
+	userID, err := UserIDFromContext(context.Background())
+	assert.Equal(t, "", userID)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "facebook: Context missing Facebook user ID", err.Error())
+	}
+}
+
+func TestContextConfirmationCode(t *testing.T) {
+	ctx := WithConfirmationCode(context.Background(), "abc123")
+	code, err := ConfirmationCodeFromContext(ctx)
+	assert.Equal(t, "abc123", code)
+	assert.Nil(t, err)
+}
+
+func TestContextConfirmationCode_Error(t *testing.T) {
+	code, err := ConfirmationCodeFromContext(context.Background())
+	assert.Equal(t, "", code)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "facebook: Context missing confirmation code", err.Error())
+	}
+}
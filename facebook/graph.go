@@ -0,0 +1,85 @@
+package facebook
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// DefaultVersion is the default Facebook Graph API version.
+const DefaultVersion = "v21.0"
+
+// DefaultFields are the default User fields requested from the Graph API.
+var DefaultFields = []string{"email", "picture{url}", "name"}
+
+// Facebook access token validation errors
+var (
+	ErrInvalidToken  = errors.New("facebook: access token is invalid")
+	ErrTokenWrongApp = errors.New("facebook: access token was issued to another app")
+	ErrTokenExpired  = errors.New("facebook: access token is expired")
+)
+
+// GraphConfig configures Facebook Graph API requests.
+type GraphConfig struct {
+	// Version is the Graph API version (e.g. "v21.0"). Defaults to
+	// DefaultVersion.
+	Version string
+	// Fields are the User fields to request. Defaults to DefaultFields.
+	Fields []string
+	// DebugToken validates access tokens with debug_token before getting the
+	// User, rejecting tokens issued to other apps or expired tokens. Token
+	// handlers always validate tokens, since native clients obtain tokens
+	// themselves, so it only needs to be set for GraphCallbackHandler.
+	DebugToken bool
+}
+
+func (c GraphConfig) version() string {
+	if c.Version == "" {
+		return DefaultVersion
+	}
+	return c.Version
+}
+
+func (c GraphConfig) fields() []string {
+	if len(c.Fields) == 0 {
+		return DefaultFields
+	}
+	return c.Fields
+}
+
+// GraphCallbackHandler handles Facebook redirection URI requests like
+// CallbackHandler, but makes Graph API requests as configured by the
+// GraphConfig.
+func GraphCallbackHandler(config *oauth2.Config, graph GraphConfig, success, failure http.Handler) http.Handler {
+	success = facebookHandler(config, graph, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// GraphTokenHandler receives a Facebook access token like TokenHandler, but
+// makes Graph API requests as configured by the GraphConfig. Tokens are
+// always validated with debug_token.
+func GraphTokenHandler(config *oauth2.Config, graph GraphConfig, success, failure http.Handler) http.Handler {
+	graph.DebugToken = true
+	success = facebookHandler(config, graph, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
+
+// validateToken returns an error if the debug_token metadata shows the token
+// is invalid, expired, or was issued to another app than the app ID. Returns
+// nil if the token is valid.
+func validateToken(token *debugToken, resp *http.Response, err error, appID string) error {
+	if err != nil || resp.StatusCode != http.StatusOK || token == nil || !token.IsValid {
+		return ErrInvalidToken
+	}
+	if token.AppID != appID {
+		return ErrTokenWrongApp
+	}
+	// expires_at is 0 for tokens which never expire
+	if token.ExpiresAt != 0 && time.Unix(token.ExpiresAt, 0).Before(time.Now()) {
+		return ErrTokenExpired
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package facebook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestAppSecretProof(t *testing.T) {
+	// hex HMAC-SHA256 of "any-token" keyed by "app-secret"
+	expected := "c2b8c12476c8105c1328576ae08807c4d579baaea5deadf9aa598133cdb2e60c"
+	assert.Equal(t, expected, appSecretProof("app-secret", "any-token"))
+	assert.Equal(t, "", appSecretProof("", "any-token"))
+}
+
+func TestFacebookHandler_GraphConfig(t *testing.T) {
+	debugJSON := fmt.Sprintf(`{"data": {"app_id": "app-id", "is_valid": true, "expires_at": %d, "user_id": "54638001"}}`, time.Now().Add(time.Hour).Unix())
+	proxyClient, server := newGraphTestServer("v20.0", debugJSON, appSecretProof("app-secret", "any-token"))
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{ClientID: "app-id", ClientSecret: "app-secret"}
+	graph := GraphConfig{Version: "v20.0", Fields: []string{"id", "first_name"}, DebugToken: true}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		facebookUser, err := UserFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, &User{ID: "54638001", FirstName: "Ivy"}, facebookUser)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// FacebookHandler with a GraphConfig, assert that:
+	// - the token is validated with debug_token
+	// - the User is requested from the version with the appsecret_proof
+	handler := facebookHandler(config, graph, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestFacebookHandler_DebugTokenInvalid(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	cases := []struct {
+		debugJSON string
+		expected  error
+	}{
+		{fmt.Sprintf(`{"data": {"app_id": "other-app", "is_valid": true, "expires_at": %d}}`, future), ErrTokenWrongApp},
+		{fmt.Sprintf(`{"data": {"app_id": "app-id", "is_valid": true, "expires_at": %d}}`, time.Now().Add(-time.Hour).Unix()), ErrTokenExpired},
+		{`{"data": {"app_id": "app-id", "is_valid": false}}`, ErrInvalidToken},
+	}
+	for _, c := range cases {
+		proxyClient, server := newGraphTestServer(DefaultVersion, c.debugJSON, appSecretProof("app-secret", "any-token"))
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+		config := &oauth2.Config{ClientID: "app-id", ClientSecret: "app-secret"}
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			assert.Equal(t, c.expected, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		handler := facebookHandler(config, GraphConfig{DebugToken: true}, success, http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestFacebookHandler_WrongAppSecretProof(t *testing.T) {
+	proxyClient, server := newGraphTestServer(DefaultVersion, "", appSecretProof("app-secret", "any-token"))
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{ClientID: "app-id", ClientSecret: "other-secret"}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := facebookHandler(config, GraphConfig{}, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestGraphConfig_Defaults(t *testing.T) {
+	graph := GraphConfig{}
+	assert.Equal(t, DefaultVersion, graph.version())
+	assert.Equal(t, DefaultFields, graph.fields())
+}
+
+func TestTokenHandler_TokenWrongApp(t *testing.T) {
+	debugJSON := fmt.Sprintf(`{"data": {"app_id": "other-app", "is_valid": true, "expires_at": %d}}`, time.Now().Add(time.Hour).Unix())
+	proxyClient, server := newGraphTestServer(DefaultVersion, debugJSON, appSecretProof("app-secret", "any-token"))
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: "app-id", ClientSecret: "app-secret"}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrTokenWrongApp, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler receives a token issued to another app, assert that:
+	// - the token is validated with debug_token without opting in
+	// - failure handler is called with ErrTokenWrongApp
+	handler := TokenHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer any-token")
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
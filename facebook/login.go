@@ -37,16 +37,21 @@ func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
 // Facebook access token and User to the ctx. If authentication succeeds,
 // handling delegates to the success handler, otherwise to the failure
 // handler.
+//
+// Graph API requests use the DefaultVersion and DefaultFields and include the
+// appsecret_proof of the access token. Use GraphCallbackHandler to configure
+// them.
 func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
-	success = facebookHandler(config, success, failure)
+	success = facebookHandler(config, GraphConfig{}, success, failure)
 	return oauth2Login.CallbackHandler(config, success, failure)
 }
 
 // facebookHandler is a http.Handler that gets the OAuth2 Token from the ctx
 // to get the corresponding Facebook User. If successful, the user is added to
 // the ctx and the success handler is called. Otherwise, the failure handler
-// is called.
-func facebookHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+// is called. If the GraphConfig enables DebugToken, the token is validated
+// first.
+func facebookHandler(config *oauth2.Config, graph GraphConfig, success, failure http.Handler) http.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
@@ -58,9 +63,19 @@ func facebookHandler(config *oauth2.Config, success, failure http.Handler) http.
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		if graph.DebugToken {
+			// debug_token is called with the app access token
+			appService := newClient(oauth2.NewClient(ctx, nil), graph, config.ClientSecret)
+			debug, resp, err := appService.DebugToken(config.ClientID, token.AccessToken)
+			if err = validateToken(debug, resp, err, config.ClientID); err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(w, req.WithContext(ctx))
+				return
+			}
+		}
 		httpClient := config.Client(ctx, token)
-		facebookService := newClient(httpClient)
-		user, resp, err := facebookService.Me()
+		facebookService := newClient(httpClient, graph, config.ClientSecret)
+		user, resp, err := facebookService.Me(token.AccessToken)
 		err = validateResponse(user, resp, err)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
@@ -68,6 +83,7 @@ func facebookHandler(config *oauth2.Config, success, failure http.Handler) http.
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -84,3 +100,14 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Facebook User.
+func newIdentity(user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:  "facebook",
+		Subject:   user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		AvatarURL: user.Picture.Data.URL,
+	}
+}
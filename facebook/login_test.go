@@ -30,6 +30,9 @@ func TestFacebookHandler(t *testing.T) {
 		facebookUser, err := UserFromContext(ctx)
 		assert.Nil(t, err)
 		assert.Equal(t, expectedUser, facebookUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "facebook", Subject: "54638001", Name: "Ivy Crimson", Email: "ivy@harvard.edu"}, identity)
 		fmt.Fprintf(w, "success handler called")
 	}
 	failure := testutils.AssertFailureNotCalled(t)
@@ -39,7 +42,7 @@ func TestFacebookHandler(t *testing.T) {
 	// - facebook User is obtained from the facebook API
 	// - success handler is called
 	// - facebook User is added to the ctx of the success handler
-	facebookHandler := facebookHandler(config, http.HandlerFunc(success), failure)
+	facebookHandler := facebookHandler(config, GraphConfig{}, http.HandlerFunc(success), failure)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	facebookHandler.ServeHTTP(w, req.WithContext(ctx))
@@ -61,7 +64,7 @@ func TestFacebookHandler_MissingCtxToken(t *testing.T) {
 	// FacebookHandler called without Token in ctx, assert that:
 	// - failure handler is called
 	// - error about ctx missing token is added to the failure handler ctx
-	facebookHandler := facebookHandler(config, success, http.HandlerFunc(failure))
+	facebookHandler := facebookHandler(config, GraphConfig{}, success, http.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	facebookHandler.ServeHTTP(w, req)
@@ -88,7 +91,7 @@ func TestFacebookHandler_ErrorGettingUser(t *testing.T) {
 	// FacebookHandler cannot get Facebook User, assert that:
 	// - failure handler is called
 	// - error cannot get Facebook User added to the failure handler ctx
-	facebookHandler := facebookHandler(config, success, http.HandlerFunc(failure))
+	facebookHandler := facebookHandler(config, GraphConfig{}, success, http.HandlerFunc(failure))
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	facebookHandler.ServeHTTP(w, req.WithContext(ctx))
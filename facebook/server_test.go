@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 
 	"github.com/dghubble/gologin/testutils"
 )
@@ -13,9 +14,41 @@ import (
 // responds with the given json data. The caller must close the server.
 func newFacebookTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	client, mux, server := testutils.TestServer()
-	mux.HandleFunc("/v2.9/me", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/"+DefaultVersion+"/me", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprintf(w, jsonData)
 	})
 	return client, server
 }
+
+// newGraphTestServer returns a new httptest.Server which mocks the Facebook
+// debug_token and user endpoints of the Graph API version and a client which
+// proxies requests to the server. The user endpoint requires the expected
+// appsecret_proof and responds with the requested fields. The caller must
+// close the server.
+func newGraphTestServer(version, debugJSON, proof string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/"+version+"/debug_token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("access_token") != "app-id|app-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "invalid app access token", "code": 190}}`)
+			return
+		}
+		fmt.Fprint(w, debugJSON)
+	})
+	mux.HandleFunc("/"+version+"/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("appsecret_proof") != proof {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "invalid appsecret_proof", "code": 100}}`)
+			return
+		}
+		if strings.Contains(r.URL.Query().Get("fields"), "first_name") {
+			fmt.Fprint(w, `{"id": "54638001", "first_name": "Ivy"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id": "54638001"}`)
+	})
+	return client, server
+}
@@ -0,0 +1,211 @@
+package facebook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+const signedRequestField = "signed_request"
+
+// SignedRequestMaxAge is the default SignedRequestConfig MaxAge.
+const SignedRequestMaxAge = 5 * time.Minute
+
+// SignedRequestConfig configures the verification of signed_requests.
+type SignedRequestConfig struct {
+	// MaxAge bounds how long ago (or, for clock skew, how far in the future)
+	// a signed_request may have been issued, so older requests are rejected
+	// as replays. Facebook retries failed callbacks with the original
+	// signed_request, so raise it to accept late retries. Defaults to
+	// SignedRequestMaxAge.
+	MaxAge time.Duration
+}
+
+func (c SignedRequestConfig) maxAge() time.Duration {
+	if c.MaxAge <= 0 {
+		return SignedRequestMaxAge
+	}
+	return c.MaxAge
+}
+
+// Facebook signed request errors
+var (
+	ErrMissingSignedRequest = fmt.Errorf("facebook: missing field %s", signedRequestField)
+	ErrInvalidSignedRequest = errors.New("facebook: invalid signed_request")
+	ErrExpiredSignedRequest = errors.New("facebook: signed_request issued_at is too old or in the future")
+)
+
+// SignedRequest is the payload of a Facebook signed_request.
+//
+// ref: https://developers.facebook.com/docs/games/gamesonfacebook/login#parsingsr
+type SignedRequest struct {
+	Algorithm string `json:"algorithm"`
+	IssuedAt  int64  `json:"issued_at"`
+	UserID    string `json:"user_id"`
+}
+
+// ParseSignedRequest verifies the HMAC-SHA256 signature of a Facebook
+// signed_request with the app secret and returns its payload. Requests whose
+// issued_at is not within SignedRequestMaxAge of now are rejected with
+// ErrExpiredSignedRequest.
+func ParseSignedRequest(signedRequest, appSecret string) (*SignedRequest, error) {
+	return SignedRequestConfig{}.Parse(signedRequest, appSecret)
+}
+
+// Parse verifies a Facebook signed_request like ParseSignedRequest, but
+// rejects requests whose issued_at is not within the config MaxAge of now.
+func (c SignedRequestConfig) Parse(signedRequest, appSecret string) (*SignedRequest, error) {
+	return parseSignedRequest(signedRequest, appSecret, c.maxAge(), time.Now())
+}
+
+// parseSignedRequest parses the signed_request like ParseSignedRequest,
+// checking its issued_at is within maxAge of the given time.
+func parseSignedRequest(signedRequest, appSecret string, maxAge time.Duration, now time.Time) (*SignedRequest, error) {
+	parts := strings.SplitN(signedRequest, ".", 2)
+	if len(parts) != 2 || appSecret == "" {
+		return nil, ErrInvalidSignedRequest
+	}
+	signature, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrInvalidSignedRequest
+	}
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write([]byte(parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrInvalidSignedRequest
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidSignedRequest
+	}
+	request := new(SignedRequest)
+	if err := json.Unmarshal(payload, request); err != nil {
+		return nil, ErrInvalidSignedRequest
+	}
+	if !strings.EqualFold(request.Algorithm, "HMAC-SHA256") || request.UserID == "" {
+		return nil, ErrInvalidSignedRequest
+	}
+	if request.IssuedAt == 0 {
+		return nil, ErrInvalidSignedRequest
+	}
+	if age := now.Sub(time.Unix(request.IssuedAt, 0)); age > maxAge || age < -maxAge {
+		return nil, ErrExpiredSignedRequest
+	}
+	return request, nil
+}
+
+// decodeSegment decodes base64url, with or without padding.
+func decodeSegment(segment string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+}
+
+// DeauthorizeHandler handles Facebook deauthorize callback requests, sent
+// when a user removes the app. It verifies the signed_request with the config
+// ClientSecret, as configured by the SignedRequestConfig, and adds the
+// Facebook user ID to the ctx. If verification succeeds, handling delegates
+// to the success handler, otherwise to the failure handler.
+func DeauthorizeHandler(config *oauth2.Config, signed SignedRequestConfig, success, failure http.Handler) http.Handler {
+	return signedRequestHandler(config, signed, success, failure)
+}
+
+// DataDeletionHandler handles Facebook data deletion callback requests. It
+// verifies the signed_request with the config ClientSecret, as configured by
+// the SignedRequestConfig, adds the Facebook user ID and a new confirmation
+// code to the ctx, and calls the success handler, which should start
+// deleting the user's data. Then it responds
+// with the JSON Facebook expects, whose status URL is the statusURL with the
+// confirmation code as the "code" query parameter. If the success handler
+// writes a response (e.g. an error), it is sent instead.
+//
+// If verification fails, handling delegates to the failure handler.
+//
+// ref: https://developers.facebook.com/docs/development/create-an-app/app-dashboard/data-deletion-callback
+func DataDeletionHandler(config *oauth2.Config, signed SignedRequestConfig, statusURL string, success, failure http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		code := internal.RandomValue()
+		ctx := WithConfirmationCode(req.Context(), code)
+		tw := &trackingWriter{ResponseWriter: w}
+		success.ServeHTTP(tw, req.WithContext(ctx))
+		if tw.wrote {
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"url":               withCode(statusURL, code),
+			"confirmation_code": code,
+		})
+	}
+	return signedRequestHandler(config, signed, http.HandlerFunc(fn), failure)
+}
+
+// signedRequestHandler is a http.Handler that verifies the signed_request of
+// a POST request and adds its Facebook user ID to the ctx. If successful, the
+// success handler is called. Otherwise, the failure handler is called.
+func signedRequestHandler(config *oauth2.Config, signed SignedRequestConfig, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if req.Method != "POST" {
+			ctx = gologin.WithError(ctx, fmt.Errorf("Method not allowed"))
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		req.ParseForm()
+		signedRequest := req.PostForm.Get(signedRequestField)
+		if signedRequest == "" {
+			ctx = gologin.WithError(ctx, ErrMissingSignedRequest)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		request, err := signed.Parse(signedRequest, config.ClientSecret)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithUserID(ctx, request.UserID)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// withCode returns the status URL with the confirmation code query parameter.
+func withCode(statusURL, code string) string {
+	u, err := url.Parse(statusURL)
+	if err != nil {
+		return statusURL
+	}
+	query := u.Query()
+	query.Set("code", code)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// trackingWriter records whether a response was written.
+type trackingWriter struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(b)
+}
@@ -0,0 +1,222 @@
+package facebook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+// testSignedRequest returns a signed_request of the payload signed with the
+// app secret.
+func testSignedRequest(payload, appSecret string) string {
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) + "." + encoded
+}
+
+// testPayload returns a signed_request payload for the user issued now.
+func testPayload(userID string) string {
+	return fmt.Sprintf(`{"algorithm": "HMAC-SHA256", "issued_at": %d, "user_id": %q}`, time.Now().Unix(), userID)
+}
+
+// newSignedRequest returns a POST request with the signed_request form field.
+func newSignedRequest(signedRequest string) *http.Request {
+	form := url.Values{signedRequestField: {signedRequest}}
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestParseSignedRequest(t *testing.T) {
+	signed := testSignedRequest(`{"algorithm": "HMAC-SHA256", "issued_at": 1291836800, "user_id": "54638001"}`, "app-secret")
+	request, err := parseSignedRequest(signed, "app-secret", SignedRequestMaxAge, time.Unix(1291836860, 0))
+	assert.Nil(t, err)
+	assert.Equal(t, &SignedRequest{Algorithm: "HMAC-SHA256", IssuedAt: 1291836800, UserID: "54638001"}, request)
+	// issued now
+	request, err = ParseSignedRequest(testSignedRequest(testPayload("54638001"), "app-secret"), "app-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "54638001", request.UserID)
+}
+
+func TestParseSignedRequest_Expired(t *testing.T) {
+	signed := testSignedRequest(`{"algorithm": "HMAC-SHA256", "issued_at": 1291836800, "user_id": "54638001"}`, "app-secret")
+	issuedAt := time.Unix(1291836800, 0)
+	cases := []time.Time{
+		// replayed after the max age
+		issuedAt.Add(SignedRequestMaxAge + time.Second),
+		// issued too far in the future
+		issuedAt.Add(-SignedRequestMaxAge - time.Second),
+	}
+	for _, now := range cases {
+		request, err := parseSignedRequest(signed, "app-secret", SignedRequestMaxAge, now)
+		assert.Nil(t, request)
+		assert.Equal(t, ErrExpiredSignedRequest, err)
+	}
+	// within the clock skew
+	_, err := parseSignedRequest(signed, "app-secret", SignedRequestMaxAge, issuedAt.Add(-time.Minute))
+	assert.Nil(t, err)
+}
+
+func TestSignedRequestConfig_Parse(t *testing.T) {
+	// a retry signed 10 minutes ago
+	payload := fmt.Sprintf(`{"algorithm": "HMAC-SHA256", "issued_at": %d, "user_id": "54638001"}`, time.Now().Add(-10*time.Minute).Unix())
+	signed := testSignedRequest(payload, "app-secret")
+	_, err := ParseSignedRequest(signed, "app-secret")
+	assert.Equal(t, ErrExpiredSignedRequest, err)
+	_, err = SignedRequestConfig{MaxAge: 5 * time.Minute}.Parse(signed, "app-secret")
+	assert.Equal(t, ErrExpiredSignedRequest, err)
+	request, err := SignedRequestConfig{MaxAge: time.Hour}.Parse(signed, "app-secret")
+	assert.Nil(t, err)
+	assert.Equal(t, "54638001", request.UserID)
+}
+
+func TestSignedRequestConfig_MaxAge(t *testing.T) {
+	assert.Equal(t, SignedRequestMaxAge, SignedRequestConfig{}.maxAge())
+	assert.Equal(t, time.Hour, SignedRequestConfig{MaxAge: time.Hour}.maxAge())
+}
+
+func TestParseSignedRequest_Invalid(t *testing.T) {
+	valid := testPayload("54638001")
+	cases := []struct {
+		signedRequest string
+		appSecret     string
+	}{
+		// signed with another secret
+		{testSignedRequest(valid, "other-secret"), "app-secret"},
+		// unknown app secret
+		{testSignedRequest(valid, ""), ""},
+		// unsupported algorithm
+		{testSignedRequest(`{"algorithm": "HMAC-SHA1", "issued_at": 1291836800, "user_id": "54638001"}`, "app-secret"), "app-secret"},
+		// missing user
+		{testSignedRequest(`{"algorithm": "HMAC-SHA256", "issued_at": 1291836800}`, "app-secret"), "app-secret"},
+		// missing issued_at
+		{testSignedRequest(`{"algorithm": "HMAC-SHA256", "user_id": "54638001"}`, "app-secret"), "app-secret"},
+		// not JSON
+		{testSignedRequest("not-json", "app-secret"), "app-secret"},
+		// malformed
+		{"no-separator", "app-secret"},
+		{"!!!." + base64.RawURLEncoding.EncodeToString([]byte(valid)), "app-secret"},
+	}
+	for _, c := range cases {
+		request, err := ParseSignedRequest(c.signedRequest, c.appSecret)
+		assert.Nil(t, request)
+		assert.Equal(t, ErrInvalidSignedRequest, err)
+	}
+}
+
+func TestDeauthorizeHandler(t *testing.T) {
+	config := &oauth2.Config{ClientSecret: "app-secret"}
+	signed := testSignedRequest(testPayload("54638001"), "app-secret")
+	success := func(w http.ResponseWriter, req *http.Request) {
+		userID, err := UserIDFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "54638001", userID)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// DeauthorizeHandler assert that:
+	// - signed_request is verified
+	// - Facebook user ID is added to the ctx of the success handler
+	handler := DeauthorizeHandler(config, SignedRequestConfig{}, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(signed))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestDeauthorizeHandler_Invalid(t *testing.T) {
+	config := &oauth2.Config{ClientSecret: "app-secret"}
+	signed := testSignedRequest(testPayload("54638001"), "other-secret")
+	replayed := testSignedRequest(`{"algorithm": "HMAC-SHA256", "issued_at": 1291836800, "user_id": "54638001"}`, "app-secret")
+	getRequest, _ := http.NewRequest("GET", "/", nil)
+	cases := []struct {
+		req      *http.Request
+		expected string
+	}{
+		{newSignedRequest(signed), ErrInvalidSignedRequest.Error()},
+		{newSignedRequest(replayed), ErrExpiredSignedRequest.Error()},
+		{newSignedRequest(""), ErrMissingSignedRequest.Error()},
+		{getRequest, "Method not allowed"},
+	}
+	for _, c := range cases {
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			if assert.NotNil(t, err) {
+				assert.Equal(t, c.expected, err.Error())
+			}
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		handler := DeauthorizeHandler(config, SignedRequestConfig{}, success, http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, c.req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestDataDeletionHandler(t *testing.T) {
+	config := &oauth2.Config{ClientSecret: "app-secret"}
+	signed := testSignedRequest(testPayload("54638001"), "app-secret")
+	var code string
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		userID, err := UserIDFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "54638001", userID)
+		code, err = ConfirmationCodeFromContext(ctx)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, code)
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// DataDeletionHandler assert that:
+	// - Facebook user ID and a confirmation code are added to the ctx
+	// - the confirmation code JSON is written after the success handler
+	handler := DataDeletionHandler(config, SignedRequestConfig{}, "https://example.com/deletion?lang=en", http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(signed))
+	assert.Equal(t, "application/json", w.HeaderMap.Get("Content-Type"))
+	var body struct {
+		URL              string `json:"url"`
+		ConfirmationCode string `json:"confirmation_code"`
+	}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, code, body.ConfirmationCode)
+	statusURL, err := url.Parse(body.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", statusURL.Host)
+	assert.Equal(t, code, statusURL.Query().Get("code"))
+	assert.Equal(t, "en", statusURL.Query().Get("lang"))
+}
+
+func TestDataDeletionHandler_SuccessResponds(t *testing.T) {
+	config := &oauth2.Config{ClientSecret: "app-secret"}
+	signed := testSignedRequest(testPayload("54638001"), "app-secret")
+	success := func(w http.ResponseWriter, req *http.Request) {
+		http.Error(w, "deletion queue unavailable", http.StatusServiceUnavailable)
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// DataDeletionHandler whose success handler responds, assert that its
+	// response is sent instead of the confirmation code JSON
+	handler := DataDeletionHandler(config, SignedRequestConfig{}, "https://example.com/deletion", http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(signed))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "deletion queue unavailable\n", w.Body.String())
+}
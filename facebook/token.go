@@ -0,0 +1,20 @@
+package facebook
+
+import (
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenHandler receives a Facebook access token obtained by a native (e.g.
+// mobile) client and gets the corresponding Facebook User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are validated with
+// debug_token and only accepted if they were issued to the config ClientID
+// and have not expired. See oauth2.TokenHandler for the accepted request
+// formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	return GraphTokenHandler(config, GraphConfig{}, success, failure)
+}
@@ -1,12 +1,16 @@
 package facebook
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"net/http"
+	"strings"
 
 	"github.com/dghubble/sling"
 )
 
-const facebookAPI = "https://graph.facebook.com/v2.9/"
+const facebookAPI = "https://graph.facebook.com/"
 
 // User is a Facebook user.
 //
@@ -20,27 +24,101 @@ type User struct {
 			URL string `json:"url"`
 		} `json:"data"`
 	} `json:"picture"`
+	// FirstName and LastName are only set if requested in the GraphConfig
+	// Fields.
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// debugToken is the metadata of an access token.
+//
+// ref: https://developers.facebook.com/docs/graph-api/reference/v21.0/debug_token
+type debugToken struct {
+	AppID     string `json:"app_id"`
+	Type      string `json:"type"`
+	ExpiresAt int64  `json:"expires_at"`
+	IsValid   bool   `json:"is_valid"`
+	UserID    string `json:"user_id"`
+}
+
+// graphError is a Facebook Graph API error response.
+type graphError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Code    int    `json:"code"`
+	} `json:"error"`
 }
 
 // client is a Facebook client for obtaining the current User.
 type client struct {
-	c     *http.Client
-	sling *sling.Sling
+	c      *http.Client
+	sling  *sling.Sling
+	fields string
+	// secret is the app secret for appsecret_proof
+	secret string
 }
 
-func newClient(httpClient *http.Client) *client {
-	base := sling.New().Client(httpClient).Base(facebookAPI)
+// newClient returns a Facebook Graph API client of the GraphConfig version.
+// The httpClient must be authorized with an access token (e.g.
+// config.Client(ctx, token)).
+func newClient(httpClient *http.Client, graph GraphConfig, secret string) *client {
+	base := sling.New().Client(httpClient).Base(facebookAPI + graph.version() + "/")
 	return &client{
-		c:     httpClient,
-		sling: base,
+		c:      httpClient,
+		sling:  base,
+		fields: strings.Join(graph.fields(), ","),
+		secret: secret,
 	}
 }
 
-func (c *client) Me() (*User, *http.Response, error) {
+// Me gets the User with the access token's appsecret_proof.
+func (c *client) Me(accessToken string) (*User, *http.Response, error) {
+	type Params struct {
+		Fields         string `url:"fields,omitempty"`
+		AppSecretProof string `url:"appsecret_proof,omitempty"`
+	}
+	params := &Params{
+		Fields:         c.fields,
+		AppSecretProof: appSecretProof(c.secret, accessToken),
+	}
 	user := new(User)
 	// Facebook returns JSON as Content-Type text/javascript :(
 	// Set Accept header to receive proper Content-Type application/json
 	// so Sling will decode into the struct
-	resp, err := c.sling.New().Set("Accept", "application/json").Get("me?fields=email,picture{url},name").ReceiveSuccess(user)
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("me").QueryStruct(params).ReceiveSuccess(user)
 	return user, resp, err
 }
+
+// DebugToken gets the metadata of the input access token. The client must
+// not be authorized with a user access token, since debug_token is called with
+// the app access token.
+func (c *client) DebugToken(appID, inputToken string) (*debugToken, *http.Response, error) {
+	type Params struct {
+		InputToken  string `url:"input_token"`
+		AccessToken string `url:"access_token"`
+	}
+	params := &Params{
+		InputToken:  inputToken,
+		AccessToken: appID + "|" + c.secret,
+	}
+	body := new(struct {
+		Data debugToken `json:"data"`
+	})
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("debug_token").QueryStruct(params).Receive(body, new(graphError))
+	return &body.Data, resp, err
+}
+
+// appSecretProof returns the appsecret_proof of an access token, the hex
+// encoded HMAC-SHA256 of the token keyed by the app secret. Returns "" if the
+// app secret is unknown.
+//
+// ref: https://developers.facebook.com/docs/graph-api/securing-requests#appsecret_proof
+func appSecretProof(secret, accessToken string) string {
+	if secret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(accessToken))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -12,6 +12,8 @@ type key int
 
 const (
 	userKey key = iota
+	membershipKey
+	emailsKey
 )
 
 // WithUser returns a copy of ctx that stores the Github User.
@@ -27,3 +29,32 @@ func UserFromContext(ctx context.Context) (*github.User, error) {
 	}
 	return user, nil
 }
+
+// WithMembership returns a copy of ctx that stores the Github Membership.
+func WithMembership(ctx context.Context, membership *Membership) context.Context {
+	return context.WithValue(ctx, membershipKey, membership)
+}
+
+// MembershipFromContext returns the Github Membership from the ctx.
+func MembershipFromContext(ctx context.Context) (*Membership, error) {
+	membership, ok := ctx.Value(membershipKey).(*Membership)
+	if !ok {
+		return nil, fmt.Errorf("github: Context missing Github Membership")
+	}
+	return membership, nil
+}
+
+// WithEmails returns a copy of ctx that stores the Github User's emails.
+func WithEmails(ctx context.Context, emails []*github.UserEmail) context.Context {
+	return context.WithValue(ctx, emailsKey, emails)
+}
+
+// EmailsFromContext returns the Github User's emails from the ctx. Emails are
+// only added when the token has the "user:email" scope.
+func EmailsFromContext(ctx context.Context) ([]*github.UserEmail, error) {
+	emails, ok := ctx.Value(emailsKey).([]*github.UserEmail)
+	if !ok {
+		return nil, fmt.Errorf("github: Context missing Github User emails")
+	}
+	return emails, nil
+}
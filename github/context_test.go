@@ -26,3 +26,35 @@ func TestContextUser_Error(t *testing.T) {
 		assert.Equal(t, "github: Context missing Github User", err.Error())
 	}
 }
+
+func TestContextMembership(t *testing.T) {
+	expected := &Membership{Orgs: []string{"acme"}, Teams: []string{"acme/admins"}}
+	ctx := WithMembership(context.Background(), expected)
+	membership, err := MembershipFromContext(ctx)
+	assert.Equal(t, expected, membership)
+	assert.Nil(t, err)
+}
+
+func TestContextMembership_Error(t *testing.T) {
+	membership, err := MembershipFromContext(context.Background())
+	assert.Nil(t, membership)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "github: Context missing Github Membership", err.Error())
+	}
+}
+
+func TestContextEmails(t *testing.T) {
+	expected := []*github.UserEmail{{Email: github.String("alyssa@example.com")}}
+	ctx := WithEmails(context.Background(), expected)
+	emails, err := EmailsFromContext(ctx)
+	assert.Equal(t, expected, emails)
+	assert.Nil(t, err)
+}
+
+func TestContextEmails_Error(t *testing.T) {
+	emails, err := EmailsFromContext(context.Background())
+	assert.Nil(t, emails)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "github: Context missing Github User emails", err.Error())
+	}
+}
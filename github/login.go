@@ -1,8 +1,11 @@
 package github
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/dghubble/gologin"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
@@ -12,7 +15,8 @@ import (
 
 // Github login errors
 var (
-	ErrUnableToGetGithubUser = errors.New("github: unable to get Github User")
+	ErrUnableToGetGithubUser   = errors.New("github: unable to get Github User")
+	ErrUnableToGetGithubEmails = errors.New("github: unable to get Github User emails")
 )
 
 // CSRFHandler checks for a state cookie. If found, the state value is read
@@ -66,7 +70,18 @@ func githubHandler(config *oauth2.Config, success, failure http.Handler) http.Ha
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		// tokens without the user:email scope, including Github App user
+		// tokens without the email permission, are forbidden from listing
+		// emails, so fall back to the profile email on any error
+		emails, err := listEmails(ctx, githubClient)
+		if err == nil {
+			if primary := primaryEmail(emails); primary != "" {
+				user.Email = github.String(primary)
+			}
+			ctx = WithEmails(ctx, emails)
+		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user, emails))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -83,3 +98,49 @@ func validateResponse(user *github.User, resp *github.Response, err error) error
 	}
 	return nil
 }
+
+// listEmails lists the authenticated user's emails, following result pages.
+func listEmails(ctx context.Context, client *github.Client) ([]*github.UserEmail, error) {
+	var emails []*github.UserEmail
+	opts := &github.ListOptions{}
+	for {
+		page, resp, err := client.Users.ListEmails(ctx, opts)
+		if err != nil {
+			return nil, ErrUnableToGetGithubEmails
+		}
+		emails = append(emails, page...)
+		if resp.NextPage == 0 {
+			return emails, nil
+		}
+		opts.Page = resp.NextPage
+	}
+}
+
+// primaryEmail returns the primary email if it is verified, otherwise "".
+func primaryEmail(emails []*github.UserEmail) string {
+	for _, email := range emails {
+		if email.GetPrimary() && email.GetVerified() {
+			return email.GetEmail()
+		}
+	}
+	return ""
+}
+
+// newIdentity returns the Identity of the Github User. The email is verified
+// if it appears as verified in the user's emails.
+func newIdentity(user *github.User, emails []*github.UserEmail) *gologin.Identity {
+	identity := &gologin.Identity{
+		Provider:  "github",
+		Subject:   strconv.FormatInt(user.GetID(), 10),
+		Email:     user.GetEmail(),
+		Name:      user.GetName(),
+		Username:  user.GetLogin(),
+		AvatarURL: user.GetAvatarURL(),
+	}
+	for _, email := range emails {
+		if email.GetVerified() && strings.EqualFold(email.GetEmail(), identity.Email) {
+			identity.EmailVerified = true
+		}
+	}
+	return identity
+}
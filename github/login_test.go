@@ -32,6 +32,9 @@ func TestGithubHandler(t *testing.T) {
 		githubUser, err := UserFromContext(ctx)
 		assert.Nil(t, err)
 		assert.Equal(t, expectedUser, githubUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "github", Subject: "917408", Name: "Alyssa Hacker"}, identity)
 		fmt.Fprintf(w, "success handler called")
 	}
 	failure := testutils.AssertFailureNotCalled(t)
@@ -99,6 +102,91 @@ func TestGithubHandler_ErrorGettingUser(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestGithubHandler_Emails(t *testing.T) {
+	jsonData := `{"id": 917408, "login": "alyssa"}`
+	proxyClient, server := newEmailsTestServer(jsonData)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		githubUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "alyssa@example.com", githubUser.GetEmail())
+		emails, err := EmailsFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, len(emails))
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "alyssa@example.com", identity.Email)
+		assert.True(t, identity.EmailVerified)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// GithubHandler with a token which may list emails, assert that:
+	// - all pages of emails are listed and added to the ctx
+	// - the primary verified email is set on the User and Identity
+	githubHandler := githubHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	githubHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestGithubHandler_EmailsForbidden(t *testing.T) {
+	for _, status := range []int{http.StatusForbidden, http.StatusNotFound, http.StatusInternalServerError} {
+		client, mux, server := testutils.TestServer()
+		mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"id": 917408, "login": "alyssa", "email": "alyssa@public.example.com"}`)
+		})
+		mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message": "Resource not accessible by integration"}`, status)
+		})
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+		ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+		config := &oauth2.Config{}
+		success := func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			githubUser, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, "alyssa@public.example.com", githubUser.GetEmail())
+			_, err = EmailsFromContext(ctx)
+			assert.NotNil(t, err)
+			identity, err := gologin.IdentityFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, "alyssa@public.example.com", identity.Email)
+			assert.False(t, identity.EmailVerified)
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := testutils.AssertFailureNotCalled(t)
+
+		// GithubHandler with a token which cannot list emails (e.g. without
+		// the user:email scope or a Github App user token), assert that:
+		// - the login succeeds with the profile email, unverified
+		githubHandler := githubHandler(config, http.HandlerFunc(success), failure)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		githubHandler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "success handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestPrimaryEmail(t *testing.T) {
+	emails := []*github.UserEmail{
+		{Email: github.String("a@example.com"), Primary: github.Bool(false), Verified: github.Bool(true)},
+		{Email: github.String("b@example.com"), Primary: github.Bool(true), Verified: github.Bool(false)},
+	}
+	assert.Equal(t, "", primaryEmail(emails))
+	emails[1].Verified = github.Bool(true)
+	assert.Equal(t, "b@example.com", primaryEmail(emails))
+}
+
 func TestValidateResponse(t *testing.T) {
 	validUser := &github.User{ID: github.Int64(123)}
 	validResponse := &github.Response{Response: &http.Response{StatusCode: 200}}
@@ -108,3 +196,27 @@ func TestValidateResponse(t *testing.T) {
 	assert.Equal(t, ErrUnableToGetGithubUser, validateResponse(validUser, invalidResponse, nil))
 	assert.Equal(t, ErrUnableToGetGithubUser, validateResponse(&github.User{}, validResponse, nil))
 }
+
+func TestProvider(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: oauth2.Endpoint{AuthURL: "https://github.com/login/oauth/authorize"},
+	}
+	provider := Provider(config)
+	assert.Equal(t, "github", provider.Name())
+
+	// Provider LoginHandler assert that:
+	// - a state cookie is issued
+	// - the request is redirected to the Github AuthURL with the state
+	mux := http.NewServeMux()
+	gologin.NewRegistry(provider).Mount(mux, gologin.DebugOnlyCookieConfig, testutils.AssertSuccessNotCalled(t), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/github/login", nil)
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	if assert.NotEmpty(t, cookies) {
+		assert.Equal(t, "gologin-temporary-cookie-github", cookies[0].Name)
+		assert.Contains(t, w.HeaderMap.Get("Location"), "state="+cookies[0].Value)
+	}
+}
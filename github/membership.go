@@ -0,0 +1,162 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnableToGetMembership is returned when a user's organization or team
+// memberships cannot be listed.
+var ErrUnableToGetMembership = errors.New("github: unable to get Github organization and team memberships")
+
+// MembershipConfig lists the organizations and teams whose members may log in.
+// If both are empty, no user may log in.
+type MembershipConfig struct {
+	// Orgs are organization logins. If set, users must be an active member
+	// of one.
+	Orgs []string
+	// Teams are "org/team-slug" names. If set, users must also be a member
+	// of one. Teams may be set without Orgs, since team members belong to
+	// the team's organization.
+	Teams []string
+}
+
+// Membership lists the organizations and teams a Github user belongs to.
+type Membership struct {
+	// Orgs are the logins of the user's active organizations.
+	Orgs []string
+	// Teams are the user's teams as "org/team-slug" names.
+	Teams []string
+}
+
+// MembershipError is returned when a Github user is not a member of an
+// allowed organization or team.
+type MembershipError struct {
+	// Login is the user's login.
+	Login string
+	// Team is true if the user is a member of an allowed organization, but
+	// not of an allowed team.
+	Team bool
+}
+
+func (e *MembershipError) Error() string {
+	if e.Team {
+		return fmt.Sprintf("github: %s is not a member of an allowed team", e.Login)
+	}
+	return fmt.Sprintf("github: %s is not a member of an allowed organization", e.Login)
+}
+
+// MembershipHandler is a http.Handler that lists the organization and team
+// memberships of the Github User in the ctx and checks them against the
+// MembershipConfig. Chain it as the success handler of CallbackHandler. If
+// the user is a member, the Membership is added to the ctx and the success
+// handler is called. Otherwise, the failure handler is called with a
+// *MembershipError.
+//
+// The access token needs the "read:org" scope to list memberships.
+func MembershipHandler(config *oauth2.Config, membership MembershipConfig, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		githubClient := github.NewClient(config.Client(ctx, token))
+		member, err := listMembership(ctx, githubClient)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if err := membership.check(user.GetLogin(), member); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithMembership(ctx, member)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// check returns a *MembershipError if the Membership does not include an
+// allowed organization and team, or if none are allowed.
+func (c MembershipConfig) check(login string, member *Membership) error {
+	if len(c.Orgs) == 0 && len(c.Teams) == 0 {
+		return &MembershipError{Login: login}
+	}
+	if len(c.Orgs) > 0 && !containsFold(c.Orgs, member.Orgs) {
+		return &MembershipError{Login: login}
+	}
+	if len(c.Teams) > 0 && !containsFold(c.Teams, member.Teams) {
+		return &MembershipError{Login: login, Team: true}
+	}
+	return nil
+}
+
+// listMembership lists the active organization and team memberships of the
+// authenticated user, following result pages.
+func listMembership(ctx context.Context, client *github.Client) (*Membership, error) {
+	member := &Membership{Orgs: []string{}, Teams: []string{}}
+	orgOpts := &github.ListOrgMembershipsOptions{State: "active"}
+	for {
+		memberships, resp, err := client.Organizations.ListOrgMemberships(ctx, orgOpts)
+		if err != nil {
+			return nil, ErrUnableToGetMembership
+		}
+		for _, membership := range memberships {
+			member.Orgs = append(member.Orgs, membership.GetOrganization().GetLogin())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		orgOpts.Page = resp.NextPage
+	}
+	teamOpts := &github.ListOptions{}
+	for {
+		teams, resp, err := client.Teams.ListUserTeams(ctx, teamOpts)
+		if err != nil {
+			return nil, ErrUnableToGetMembership
+		}
+		for _, team := range teams {
+			member.Teams = append(member.Teams, team.GetOrganization().GetLogin()+"/"+team.GetSlug())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		teamOpts.Page = resp.NextPage
+	}
+	return member, nil
+}
+
+// containsFold returns true if any value is in allowed, ignoring case since
+// Github logins and slugs are case-insensitive.
+func containsFold(allowed, values []string) bool {
+	for _, a := range allowed {
+		for _, v := range values {
+			if strings.EqualFold(a, v) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func newMembershipContext(proxyClient *http.Client) context.Context {
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+	return WithUser(ctx, &github.User{ID: github.Int64(917408), Login: github.String("alyssa")})
+}
+
+func TestMembershipHandler(t *testing.T) {
+	proxyClient, server := newMembershipTestServer()
+	defer server.Close()
+
+	cases := []MembershipConfig{
+		{Orgs: []string{"acme"}},
+		{Orgs: []string{"other", "initech"}},
+		{Orgs: []string{"acme"}, Teams: []string{"acme/Admins"}},
+		// teams only
+		{Teams: []string{"acme/admins"}},
+	}
+	for _, c := range cases {
+		success := func(w http.ResponseWriter, req *http.Request) {
+			membership, err := MembershipFromContext(req.Context())
+			assert.Nil(t, err)
+			assert.Equal(t, &Membership{Orgs: []string{"Acme", "initech"}, Teams: []string{"Acme/admins"}}, membership)
+			fmt.Fprintf(w, "success handler called")
+		}
+
+		// MembershipHandler assert that:
+		// - org memberships are listed across pages, along with teams
+		// - members of an allowed org (and team) reach the success handler
+		// - the Membership is added to the success handler ctx
+		handler := MembershipHandler(&oauth2.Config{}, c, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(newMembershipContext(proxyClient)))
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestMembershipHandler_NotMember(t *testing.T) {
+	proxyClient, server := newMembershipTestServer()
+	defer server.Close()
+
+	cases := []struct {
+		config   MembershipConfig
+		expected *MembershipError
+	}{
+		{MembershipConfig{}, &MembershipError{Login: "alyssa"}},
+		{MembershipConfig{Orgs: []string{"other"}}, &MembershipError{Login: "alyssa"}},
+		{MembershipConfig{Teams: []string{"acme/owners"}}, &MembershipError{Login: "alyssa", Team: true}},
+		{MembershipConfig{Orgs: []string{"acme"}, Teams: []string{"acme/owners", "initech/admins"}}, &MembershipError{Login: "alyssa", Team: true}},
+	}
+	for _, c := range cases {
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			if assert.IsType(t, &MembershipError{}, err) {
+				assert.Equal(t, c.expected, err)
+			}
+			fmt.Fprintf(w, "failure handler called")
+		}
+		handler := MembershipHandler(&oauth2.Config{}, c.config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(newMembershipContext(proxyClient)))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestMembershipHandler_ErrorGettingMembership(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Github Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetMembership, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := MembershipHandler(&oauth2.Config{}, MembershipConfig{Orgs: []string{"acme"}}, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(newMembershipContext(proxyClient)))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestMembershipError(t *testing.T) {
+	assert.Equal(t, "github: alyssa is not a member of an allowed organization", (&MembershipError{Login: "alyssa"}).Error())
+	assert.Equal(t, "github: alyssa is not a member of an allowed team", (&MembershipError{Login: "alyssa", Team: true}).Error())
+}
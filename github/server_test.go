@@ -1,6 +1,7 @@
 package github
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -19,3 +20,73 @@ func newGithubTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	})
 	return client, server
 }
+
+// newTokenTestServer returns a new httptest.Server which mocks the Github
+// user endpoint and the check token endpoint of the "client_id" app, which
+// responds with the given authorization json data for "some-token", and a
+// client which proxies requests to the server. The caller must close the
+// server.
+func newTokenTestServer(jsonData, authorizationJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
+	})
+	mux.HandleFunc("/applications/client_id/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var body struct {
+			AccessToken string `json:"access_token"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		clientID, clientSecret, ok := r.BasicAuth()
+		if r.Method != "POST" || !ok || clientID != "client_id" || clientSecret != "client_secret" || body.AccessToken != "some-token" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "Not Found"}`)
+			return
+		}
+		fmt.Fprint(w, authorizationJSON)
+	})
+	return client, server
+}
+
+// newMembershipTestServer returns a new httptest.Server which mocks the Github
+// organization membership (in two pages) and team endpoints and a client
+// which proxies requests to the server. The caller must close the server.
+func newMembershipTestServer() (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/user/memberships/orgs", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<https://api.github.com/user/memberships/orgs?state=active&page=2>; rel="next"`)
+			fmt.Fprint(w, `[{"state": "active", "organization": {"login": "Acme"}}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"state": "active", "organization": {"login": "initech"}}]`)
+	})
+	mux.HandleFunc("/user/teams", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[{"slug": "admins", "organization": {"login": "Acme"}}]`)
+	})
+	return client, server
+}
+
+// newEmailsTestServer returns a new httptest.Server which mocks the Github
+// user endpoint and the user emails endpoint (in two pages) and a client
+// which proxies requests to the server. The caller must close the server.
+func newEmailsTestServer(jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("page") == "" {
+			w.Header().Set("Link", `<https://api.github.com/user/emails?page=2>; rel="next"`)
+			fmt.Fprint(w, `[{"email": "old@example.com", "primary": false, "verified": true}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"email": "alyssa@example.com", "primary": true, "verified": true}]`)
+	})
+	return client, server
+}
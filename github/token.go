@@ -0,0 +1,98 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/google/go-github/github"
+	"golang.org/x/oauth2"
+)
+
+// Github access token validation errors
+var (
+	ErrInvalidToken  = errors.New("github: access token is invalid")
+	ErrTokenWrongApp = errors.New("github: access token was issued to another app")
+)
+
+// TokenHandler receives a Github access token obtained by a native (e.g.
+// mobile) client and gets the corresponding Github User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are checked with the
+// config ClientID and ClientSecret and only accepted if they were issued to
+// the app. See oauth2.TokenHandler for the accepted request formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = githubHandler(config, success, failure)
+	success = checkTokenHandler(config, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
+
+// checkTokenHandler is a http.Handler that checks the OAuth2 Token from the
+// ctx was issued to the config ClientID. If so, the success handler is
+// called. Otherwise, the failure handler is called.
+func checkTokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		authorization, resp, err := checkToken(ctx, config, token.AccessToken)
+		err = validateAuthorization(authorization, resp, err, config.ClientID)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// checkToken gets the Authorization of the access token, authenticating as
+// the app with its client credentials. Github responds 404 Not Found if the
+// token is invalid or was issued to another app.
+//
+// ref: https://docs.github.com/en/rest/apps/oauth-applications#check-a-token
+func checkToken(ctx context.Context, config *oauth2.Config, accessToken string) (*github.Authorization, *github.Response, error) {
+	transport := &github.BasicAuthTransport{
+		Username: config.ClientID,
+		Password: config.ClientSecret,
+	}
+	// use the ctx HTTP client's transport, like config.Client
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok {
+		transport.Transport = client.Transport
+	}
+	githubClient := github.NewClient(transport.Client())
+	body := map[string]string{"access_token": accessToken}
+	req, err := githubClient.NewRequest("POST", "applications/"+url.PathEscape(config.ClientID)+"/token", body)
+	if err != nil {
+		return nil, nil, err
+	}
+	authorization := new(github.Authorization)
+	resp, err := githubClient.Do(ctx, req, authorization)
+	return authorization, resp, err
+}
+
+// validateAuthorization returns an error if the given Authorization, raw
+// http.Response, or error are unexpected or the token was issued to another
+// app than the client ID. Returns nil if the token is valid for the client.
+func validateAuthorization(authorization *github.Authorization, resp *github.Response, err error, clientID string) error {
+	if err != nil || resp.StatusCode != http.StatusOK || authorization == nil {
+		return ErrInvalidToken
+	}
+	if clientID == "" || authorization.GetApp().GetClientID() != clientID {
+		return ErrTokenWrongApp
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenHandler(t *testing.T) {
+	proxyClient, server := newTokenTestServer(`{"id": 917408, "name": "Alyssa Hacker"}`, `{"id": 1, "app": {"client_id": "client_id"}, "user": {"id": 917408}}`)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: "client_id", ClientSecret: "client_secret"}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "some-token", token.AccessToken)
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(917408), user.GetID())
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// TokenHandler assert that:
+	// - access token is read from the Authorization header
+	// - access token is checked with the app's client credentials
+	// - github User is obtained from the Github API
+	// - success handler is called with the Token and User in the ctx
+	tokenHandler := TokenHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestTokenHandler_InvalidToken(t *testing.T) {
+	proxyClient, server := newTokenTestServer(`{"id": 917408, "name": "Alyssa Hacker"}`, `{}`)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	// the check token endpoint does not know tokens of other apps
+	config := &oauth2.Config{ClientID: "client_id", ClientSecret: "client_secret"}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrInvalidToken, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler with a token Github does not recognize for the app, assert
+	// that:
+	// - failure handler is called with ErrInvalidToken
+	tokenHandler := TokenHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer other-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateAuthorization(t *testing.T) {
+	valid := &github.Authorization{App: &github.AuthorizationApp{ClientID: github.String("client_id")}}
+	validResponse := &github.Response{Response: &http.Response{StatusCode: 200}}
+	invalidResponse := &github.Response{Response: &http.Response{StatusCode: 404}}
+	assert.Equal(t, nil, validateAuthorization(valid, validResponse, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateAuthorization(valid, validResponse, fmt.Errorf("Server error"), "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateAuthorization(valid, invalidResponse, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateAuthorization(valid, validResponse, nil, "other_client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateAuthorization(&github.Authorization{}, validResponse, nil, ""))
+}
@@ -0,0 +1,3 @@
+// Package gitlab provides GitLab OAuth2 login and callback handlers for
+// gitlab.com and self-managed GitLab instances.
+package gitlab
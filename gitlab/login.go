@@ -0,0 +1,140 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// DefaultBaseURL is the base URL of gitlab.com.
+const DefaultBaseURL = "https://gitlab.com"
+
+// authorizePath is the path of the authorization endpoint under a GitLab
+// instance's base URL.
+const authorizePath = "/oauth/authorize"
+
+// GitLab login errors
+var (
+	ErrUnableToGetGitLabUser = errors.New("gitlab: unable to get GitLab User")
+)
+
+// Endpoint returns the OAuth2 Endpoint of the GitLab instance at the base URL
+// (e.g. DefaultBaseURL or "https://gitlab.example.com").
+func Endpoint(baseURL string) oauth2.Endpoint {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return oauth2.Endpoint{
+		AuthURL:  baseURL + authorizePath,
+		TokenURL: baseURL + "/oauth/token",
+	}
+}
+
+// CSRFHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester.
+//
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
+// state params differently, write a http.Handler which sets the ctx state,
+// using oauth2 WithState(ctx, state) since it is required by LoginHandler
+// and CallbackHandler.
+func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	return oauth2Login.CSRFHandler(config, success)
+}
+
+// LoginHandler handles GitLab login requests by reading the state value
+// from the ctx and redirecting requests to the AuthURL with that state value.
+func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// CallbackHandler handles GitLab redirection URI requests and adds the
+// GitLab access token and User to the ctx. If authentication succeeds,
+// handling delegates to the success handler, otherwise to the failure
+// handler.
+//
+// The User is fetched from the GitLab instance which issued the token, as
+// determined by the config Endpoint (see Endpoint).
+func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = gitlabHandler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// gitlabHandler is a http.Handler that gets the OAuth2 Token from the ctx
+// to get the corresponding GitLab User. If successful, the User is added to
+// the ctx and the success handler is called. Otherwise, the failure handler
+// is called.
+func gitlabHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	baseURL := baseURL(config.Endpoint)
+	name := ProviderName(config.Endpoint)
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		httpClient := config.Client(ctx, token)
+		gitlabClient := newClient(httpClient, baseURL)
+		user, resp, err := gitlabClient.CurrentUser()
+		err = validateResponse(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(name, user))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// baseURL returns the base URL of the GitLab instance serving the Endpoint.
+// Instances installed under a relative URL (e.g. https://example.com/gitlab)
+// are supported. Defaults to DefaultBaseURL if the Endpoint is unset.
+func baseURL(endpoint oauth2.Endpoint) string {
+	if endpoint.AuthURL == "" {
+		return DefaultBaseURL
+	}
+	if strings.HasSuffix(endpoint.AuthURL, authorizePath) {
+		return strings.TrimSuffix(endpoint.AuthURL, authorizePath)
+	}
+	u, err := url.Parse(endpoint.AuthURL)
+	if err != nil || u.Host == "" {
+		return DefaultBaseURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// validateResponse returns an error if the given GitLab User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateResponse(user *User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetGitLabUser
+	}
+	if user == nil || user.ID == 0 {
+		return ErrUnableToGetGitLabUser
+	}
+	return nil
+}
+
+// newIdentity returns the Identity of the GitLab User of the named instance.
+func newIdentity(name string, user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:  name,
+		Subject:   strconv.FormatInt(user.ID, 10),
+		Email:     user.Email,
+		Name:      user.Name,
+		Username:  user.Username,
+		AvatarURL: user.AvatarURL,
+	}
+}
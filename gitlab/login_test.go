@@ -0,0 +1,176 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestGitLabHandler(t *testing.T) {
+	cases := []struct {
+		endpoint oauth2.Endpoint
+		prefix   string
+		provider string
+	}{
+		{oauth2.Endpoint{}, "", "gitlab"},
+		{Endpoint(DefaultBaseURL), "", "gitlab"},
+		{Endpoint("https://gitlab.example.com/"), "", "gitlab-gitlab.example.com"},
+		{Endpoint("https://example.com/gitlab"), "/gitlab", "gitlab-example.com-gitlab"},
+	}
+	for _, c := range cases {
+		jsonData := `{"id": 917408, "username": "alyssa", "name": "Alyssa Hacker"}`
+		expectedUser := &User{ID: 917408, Username: "alyssa", Name: "Alyssa Hacker"}
+		proxyClient, server := newGitLabTestServer(c.prefix, jsonData)
+		// oauth2 Client will use the proxy client's base Transport
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		anyToken := &oauth2.Token{AccessToken: "any-token"}
+		ctx = oauth2Login.WithToken(ctx, anyToken)
+
+		config := &oauth2.Config{Endpoint: c.endpoint}
+		success := func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+			gitlabUser, err := UserFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedUser, gitlabUser)
+			identity, err := gologin.IdentityFromContext(ctx)
+			assert.Nil(t, err)
+			assert.Equal(t, &gologin.Identity{Provider: c.provider, Subject: "917408", Name: "Alyssa Hacker", Username: "alyssa"}, identity)
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := testutils.AssertFailureNotCalled(t)
+
+		// GitLabHandler assert that:
+		// - Token is read from the ctx and passed to the GitLab API
+		// - GitLab User is obtained from the instance's API
+		// - success handler is called
+		// - GitLab User is added to the ctx of the success handler
+		gitlabHandler := gitlabHandler(config, http.HandlerFunc(success), failure)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		gitlabHandler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "success handler called", w.Body.String())
+		server.Close()
+	}
+}
+
+func TestGitLabHandler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// GitLabHandler called without Token in ctx, assert that:
+	// - failure handler is called
+	// - error about ctx missing token is added to the failure handler ctx
+	gitlabHandler := gitlabHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	gitlabHandler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestGitLabHandler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("GitLab Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	anyToken := &oauth2.Token{AccessToken: "any-token"}
+	ctx = oauth2Login.WithToken(ctx, anyToken)
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		err := gologin.ErrorFromContext(ctx)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ErrUnableToGetGitLabUser, err)
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// GitLabHandler cannot get GitLab User, assert that:
+	// - failure handler is called
+	// - error cannot get GitLab User added to the failure handler ctx
+	gitlabHandler := gitlabHandler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	gitlabHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestEndpoint(t *testing.T) {
+	endpoint := Endpoint("https://gitlab.example.com/")
+	assert.Equal(t, "https://gitlab.example.com/oauth/authorize", endpoint.AuthURL)
+	assert.Equal(t, "https://gitlab.example.com/oauth/token", endpoint.TokenURL)
+}
+
+func TestBaseURL(t *testing.T) {
+	cases := []struct {
+		endpoint oauth2.Endpoint
+		expected string
+	}{
+		{oauth2.Endpoint{}, DefaultBaseURL},
+		{Endpoint("https://gitlab.example.com"), "https://gitlab.example.com"},
+		{Endpoint("https://example.com/gitlab"), "https://example.com/gitlab"},
+		{oauth2.Endpoint{AuthURL: "https://gitlab.example.com/custom/authorize"}, "https://gitlab.example.com"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, baseURL(c.endpoint))
+	}
+}
+
+func TestProviderName(t *testing.T) {
+	cases := []struct {
+		endpoint oauth2.Endpoint
+		expected string
+	}{
+		{oauth2.Endpoint{}, "gitlab"},
+		{Endpoint(DefaultBaseURL), "gitlab"},
+		{Endpoint("https://gitlab.example.com"), "gitlab-gitlab.example.com"},
+		{Endpoint("https://GitLab.example.com:8443/"), "gitlab-gitlab.example.com-8443"},
+		{Endpoint("https://example.com/gitlab"), "gitlab-example.com-gitlab"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ProviderName(c.endpoint))
+	}
+}
+
+func TestProvider_Instances(t *testing.T) {
+	registry := gologin.NewRegistry(
+		Provider(&oauth2.Config{Endpoint: Endpoint(DefaultBaseURL)}),
+		Provider(&oauth2.Config{Endpoint: Endpoint("https://gitlab.example.com")}),
+	)
+	// instances are mounted under their own routes without conflict
+	mux := http.NewServeMux()
+	registry.Mount(mux, gologin.DebugOnlyCookieConfig, testutils.AssertSuccessNotCalled(t), testutils.AssertFailureNotCalled(t))
+	for _, path := range []string{"/gitlab/login", "/gitlab-gitlab.example.com/login"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", path, nil)
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusFound, w.Code)
+	}
+}
+
+func TestValidateResponse(t *testing.T) {
+	validUser := &User{ID: 917408}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetGitLabUser, validateResponse(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetGitLabUser, validateResponse(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetGitLabUser, validateResponse(&User{}, validResponse, nil))
+}
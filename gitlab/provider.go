@@ -0,0 +1,46 @@
+package gitlab
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider which performs GitLab web logins with
+// the given config, for mounting with a gologin.Registry. It is named after
+// the instance of the config Endpoint (see ProviderName), so several
+// instances can be mounted together.
+func Provider(config *oauth2.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+// ProviderName returns the name of the GitLab instance serving the Endpoint,
+// used as the Identity Provider and Provider route name. The name is "gitlab"
+// for gitlab.com and "gitlab-{host}" for self-managed instances (e.g.
+// "gitlab-gitlab.example.com"), since user IDs are only unique within an
+// instance.
+func ProviderName(endpoint oauth2.Endpoint) string {
+	base := baseURL(endpoint)
+	if base == DefaultBaseURL {
+		return "gitlab"
+	}
+	return internal.InstanceName("gitlab", base)
+}
+
+type provider struct {
+	config *oauth2.Config
+}
+
+func (p *provider) Name() string {
+	return ProviderName(p.config.Endpoint)
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, CallbackHandler(p.config, success, failure))
+}
@@ -0,0 +1,44 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/dghubble/gologin/testutils"
+)
+
+// newGitLabTestServer returns a new httptest.Server which mocks the GitLab
+// user endpoint under the given path prefix and a client which proxies
+// requests to the server. The server responds with the given json data. The
+// caller must close the server.
+func newGitLabTestServer(prefix, jsonData string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc(prefix+"/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, jsonData)
+	})
+	return client, server
+}
+
+// newTokenInfoTestServer returns a new httptest.Server which mocks the GitLab
+// user and token info endpoints of gitlab.com and a client which proxies
+// requests to the server. The token info endpoint responds with the given
+// tokenInfoJSON for "some-token". The caller must close the server.
+func newTokenInfoTestServer(jsonData, tokenInfoJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
+	})
+	mux.HandleFunc("/oauth/token/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer some-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_token"}`)
+			return
+		}
+		fmt.Fprint(w, tokenInfoJSON)
+	})
+	return client, server
+}
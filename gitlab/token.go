@@ -0,0 +1,72 @@
+package gitlab
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// GitLab access token validation errors
+var (
+	ErrInvalidToken  = errors.New("gitlab: access token is invalid")
+	ErrTokenWrongApp = errors.New("gitlab: access token was issued to another app")
+)
+
+// TokenHandler receives a GitLab access token obtained by a native (e.g.
+// mobile) client and gets the corresponding GitLab User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are only accepted if
+// the instance's token info shows they were issued to the config ClientID.
+// See oauth2.TokenHandler for the accepted request formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = gitlabHandler(config, success, failure)
+	success = tokenInfoHandler(config, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
+
+// tokenInfoHandler is a http.Handler that checks the OAuth2 Token from the
+// ctx was issued to the config ClientID. If so, the success handler is
+// called. Otherwise, the failure handler is called.
+func tokenInfoHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	baseURL := baseURL(config.Endpoint)
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		gitlabClient := newClient(config.Client(ctx, token), baseURL)
+		info, resp, err := gitlabClient.TokenInfo()
+		err = validateTokenInfo(info, resp, err, config.ClientID)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// validateTokenInfo returns an error if the given tokenInfo, raw
+// http.Response, or error are unexpected or the token was issued to another
+// app than the client ID. Returns nil if the token is valid for the client.
+func validateTokenInfo(info *tokenInfo, resp *http.Response, err error, clientID string) error {
+	if err != nil || resp.StatusCode != http.StatusOK || info == nil {
+		return ErrInvalidToken
+	}
+	if clientID == "" || info.Application.UID != clientID {
+		return ErrTokenWrongApp
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenHandler(t *testing.T) {
+	proxyClient, server := newTokenInfoTestServer(`{"id": 1, "username": "tanuki"}`, `{"resource_owner_id": 1, "scope": ["read_user"], "application": {"uid": "client_id"}}`)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: "client_id"}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "some-token", token.AccessToken)
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &User{ID: 1, Username: "tanuki"}, user)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// TokenHandler assert that:
+	// - access token application is checked with the token info endpoint
+	// - gitlab User is obtained from the GitLab API
+	// - success handler is called with the Token and User in the ctx
+	tokenHandler := TokenHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestTokenHandler_TokenWrongApp(t *testing.T) {
+	proxyClient, server := newTokenInfoTestServer(`{"id": 1, "username": "tanuki"}`, `{"resource_owner_id": 1, "application": {"uid": "other_client_id"}}`)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: "client_id"}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrTokenWrongApp, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler receives a token issued to another app, assert that:
+	// - failure handler is called with ErrTokenWrongApp
+	tokenHandler := TokenHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateTokenInfo(t *testing.T) {
+	valid := &tokenInfo{}
+	valid.Application.UID = "client_id"
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 401}
+	assert.Equal(t, nil, validateTokenInfo(valid, validResponse, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokenInfo(valid, validResponse, fmt.Errorf("Server error"), "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokenInfo(valid, invalidResponse, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokenInfo(valid, validResponse, nil, "other_client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokenInfo(&tokenInfo{}, validResponse, nil, ""))
+}
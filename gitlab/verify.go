@@ -0,0 +1,61 @@
+package gitlab
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/sling"
+)
+
+// User is a GitLab user.
+//
+// ref: https://docs.gitlab.com/ee/api/users.html#list-current-user
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	State     string `json:"state"`
+	AvatarURL string `json:"avatar_url"`
+	WebURL    string `json:"web_url"`
+}
+
+// tokenInfo describes a GitLab access token.
+//
+// ref: https://docs.gitlab.com/ee/api/oauth2.html#retrieve-the-token-information
+type tokenInfo struct {
+	ResourceOwnerID int64 `json:"resource_owner_id"`
+	Application     struct {
+		// UID is the client ID of the application the token was issued to.
+		UID string `json:"uid"`
+	} `json:"application"`
+}
+
+// client is a GitLab client for obtaining the current User.
+type client struct {
+	sling *sling.Sling
+	oauth *sling.Sling
+}
+
+// newClient returns a new GitLab client for the instance at the base URL.
+func newClient(httpClient *http.Client, baseURL string) *client {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	return &client{
+		sling: sling.New().Client(httpClient).Base(baseURL + "/api/v4/"),
+		oauth: sling.New().Client(httpClient).Base(baseURL + "/oauth/"),
+	}
+}
+
+// CurrentUser gets the current user's profile information.
+func (c *client) CurrentUser() (*User, *http.Response, error) {
+	user := new(User)
+	resp, err := c.sling.New().Get("user").ReceiveSuccess(user)
+	return user, resp, err
+}
+
+// TokenInfo gets the tokenInfo of the client's access token.
+func (c *client) TokenInfo() (*tokenInfo, *http.Response, error) {
+	info := new(tokenInfo)
+	resp, err := c.oauth.New().Get("token/info").ReceiveSuccess(info)
+	return info, resp, err
+}
@@ -0,0 +1,106 @@
+package google
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Issuer is the Google OpenID Connect issuer.
+const Issuer = "https://accounts.google.com"
+
+// ErrHostedDomainNotAllowed is returned when the user's hosted domain is not
+// one of the allowed domains.
+var ErrHostedDomainNotAllowed = errors.New("google: user's hosted domain is not allowed")
+
+// NewVerifier uses OpenID Connect discovery to return an oidc.Verifier of
+// Google ID Tokens issued to the client ID. The ctx is retained to fetch
+// signing keys.
+func NewVerifier(ctx context.Context, clientID string) (oidc.Verifier, error) {
+	return oidc.NewVerifier(ctx, Issuer, clientID)
+}
+
+// DomainLoginHandler handles Google login requests like LoginHandler, but
+// asks Google to only offer accounts of the hosted (Google Workspace)
+// domains. With one domain, the "hd" parameter names it, otherwise any
+// Workspace account is offered. See LoginHandler for the opts.
+//
+// The "hd" parameter is only a hint to Google's account chooser, so always
+// use DomainCallbackHandler to check the user's domain. The ID Token nonce is
+// read from the ctx, so wrap both handlers with oidc.CSRFHandler.
+func DomainLoginHandler(config *oauth2.Config, domains []string, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
+	hd := "*"
+	if len(domains) == 1 {
+		hd = domains[0]
+	}
+	// later options override earlier ones of the same parameter
+	opts = append([]oauth2.AuthCodeOption{PromptSelectAccount, oauth2.SetAuthURLParam("hd", hd)}, opts...)
+	return oidc.LoginHandler(config, failure, opts...)
+}
+
+// DomainCallbackHandler handles Google redirection URI requests like
+// CallbackHandler, but first verifies the ID Token (see oidc.IDTokenHandler)
+// and checks that its "hd" claim is one of the hosted domains. Users of other
+// domains and personal Google accounts are rejected with
+// ErrHostedDomainNotAllowed.
+//
+// The config scopes must include "openid" so Google issues an ID Token.
+func DomainCallbackHandler(config *oauth2.Config, verifier oidc.Verifier, domains []string, success, failure http.Handler) http.Handler {
+	success = googleHandler(config, success, failure)
+	success = hostedDomainHandler(domains, success, failure)
+	success = oidc.IDTokenHandler(verifier, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// hostedDomainHandler is a http.Handler that gets the verified ID Token from
+// the ctx and checks its hosted domain. If the domain is allowed, the success
+// handler is called. Otherwise, the failure handler is called.
+func hostedDomainHandler(domains []string, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		idToken, err := oidc.IDTokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		var claims struct {
+			HostedDomain string `json:"hd"`
+		}
+		if err := idToken.Claims(&claims); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if !allowedDomain(domains, claims.HostedDomain) {
+			ctx = gologin.WithError(ctx, ErrHostedDomainNotAllowed)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// allowedDomain returns true if the hosted domain is one of the domains.
+// Personal accounts have no hosted domain and are never allowed.
+func allowedDomain(domains []string, hd string) bool {
+	if hd == "" {
+		return false
+	}
+	for _, domain := range domains {
+		if strings.EqualFold(domain, hd) {
+			return true
+		}
+	}
+	return false
+}
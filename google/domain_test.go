@@ -0,0 +1,145 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal/oidctest"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestDomainLoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: testClientID,
+		Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.google.com/o/oauth2/auth"},
+	}
+	cases := []struct {
+		domains []string
+		hd      string
+	}{
+		{[]string{"example.com"}, "example.com"},
+		{[]string{"example.com", "example.org"}, "*"},
+	}
+	for _, c := range cases {
+		ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+		ctx = oidc.WithNonce(ctx, testNonce)
+		loginHandler := DomainLoginHandler(config, c.domains, testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		loginHandler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.HeaderMap.Get("Location"))
+		assert.Nil(t, err)
+		assert.Equal(t, c.hd, location.Query().Get("hd"))
+		assert.Equal(t, "select_account", location.Query().Get("prompt"))
+		assert.Equal(t, testNonce, location.Query().Get("nonce"))
+	}
+	// options are passed through
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+	ctx = oidc.WithNonce(ctx, testNonce)
+	loginHandler := DomainLoginHandler(config, []string{"example.com"}, testutils.AssertFailureNotCalled(t), AccessTypeOffline, PromptConsent)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "example.com", location.Query().Get("hd"))
+	assert.Equal(t, "offline", location.Query().Get("access_type"))
+	assert.Equal(t, "consent", location.Query().Get("prompt"))
+}
+
+func TestHostedDomainHandler(t *testing.T) {
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"hd": "Example.com"}),
+	})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = oidc.WithNonce(ctx, testNonce)
+
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// ID Token hosted domain is allowed, assert that:
+	// - success handler is called
+	handler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), hostedDomainHandler([]string{"example.com"}, http.HandlerFunc(success), failure), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestHostedDomainHandler_NotAllowed(t *testing.T) {
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	cases := []map[string]interface{}{
+		// other domain
+		{"hd": "example.org"},
+		// personal account
+		{},
+	}
+	for _, claims := range cases {
+		token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+			"id_token": signer.IDToken(t, testClaims, claims),
+		})
+		ctx := oauth2Login.WithToken(context.Background(), token)
+		ctx = oidc.WithNonce(ctx, testNonce)
+
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			assert.Equal(t, ErrHostedDomainNotAllowed, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		handler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), hostedDomainHandler([]string{"example.com"}, success, http.HandlerFunc(failure)), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestHostedDomainHandler_InvalidIDToken(t *testing.T) {
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	// ID Token issued to another client
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"hd": "example.com", "aud": "other-client"}),
+	})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = oidc.WithNonce(ctx, testNonce)
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), hostedDomainHandler([]string{"example.com"}, success, http.HandlerFunc(failure)), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestAllowedDomain(t *testing.T) {
+	domains := []string{"example.com", "example.org"}
+	assert.True(t, allowedDomain(domains, "example.org"))
+	assert.True(t, allowedDomain(domains, "EXAMPLE.com"))
+	assert.False(t, allowedDomain(domains, "example.net"))
+	assert.False(t, allowedDomain(domains, ""))
+	assert.False(t, allowedDomain(nil, "example.com"))
+}
@@ -28,14 +28,38 @@ func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler
 	return oauth2Login.CSRFHandler(config, success)
 }
 
+// Google authorization request options.
+//
+// ref: https://developers.google.com/identity/protocols/oauth2/web-server#creatingclient
+var (
+	// AccessTypeOffline requests a refresh token. Google only issues refresh
+	// tokens when the user consents, so pair it with PromptConsent.
+	AccessTypeOffline = oauth2.AccessTypeOffline
+	// PromptConsent asks the user to consent, even if they did before.
+	PromptConsent = oauth2.SetAuthURLParam("prompt", "consent")
+	// PromptSelectAccount allows a user who has multiple accounts at the
+	// authorization server to select amongst the multiple accounts that they
+	// may have current sessions for. LoginHandler sends it by default.
+	PromptSelectAccount = oauth2.SetAuthURLParam("prompt", "select_account")
+	// IncludeGrantedScopes requests incremental authorization, so the token
+	// covers the requested scopes and any scopes previously granted.
+	IncludeGrantedScopes = oauth2.SetAuthURLParam("include_granted_scopes", "true")
+)
+
+// LoginHint returns an option which suggests the account (an email address or
+// "sub" identifier) to log in with.
+func LoginHint(hint string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("login_hint", hint)
+}
+
 // LoginHandler handles Google login requests by reading the state value from
-// the ctx and redirecting requests to the AuthURL with that state value.
-func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
-	// PromptSelectAccount allows a user who has multiple accounts at the authorization server
-	// to select amongst the multiple accounts that they may have current sessions for.
-	// eg: https://developers.google.com/identity/protocols/OpenIDConnect
-	promptSelectAccount := oauth2.SetAuthURLParam("prompt", "select_account")
-	return oauth2Login.LoginHandler(config, failure, promptSelectAccount)
+// the ctx and redirecting requests to the AuthURL with that state value. The
+// AuthURL prompts the user to select an account, unless opts set another
+// prompt (e.g. PromptConsent).
+func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
+	// later options override earlier ones of the same parameter
+	opts = append([]oauth2.AuthCodeOption{PromptSelectAccount}, opts...)
+	return oauth2Login.LoginHandler(config, failure, opts...)
 }
 
 // CallbackHandler handles Google redirection URI requests and adds the Google
@@ -77,6 +101,7 @@ func googleHandler(config *oauth2.Config, success, failure http.Handler) http.Ha
 			return
 		}
 		ctx = WithUser(ctx, userInfoPlus)
+		ctx = gologin.WithIdentity(ctx, newIdentity(userInfoPlus))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -93,3 +118,15 @@ func validateResponse(user *google.Userinfoplus, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Google Userinfoplus.
+func newIdentity(user *google.Userinfoplus) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:      "google",
+		Subject:       user.Id,
+		Email:         user.Email,
+		EmailVerified: user.VerifiedEmail != nil && *user.VerifiedEmail,
+		Name:          user.Name,
+		AvatarURL:     user.Picture,
+	}
+}
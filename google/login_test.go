@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"github.com/dghubble/gologin"
@@ -15,6 +16,43 @@ import (
 	google "google.golang.org/api/oauth2/v2"
 )
 
+func TestLoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: testClientID,
+		Endpoint: oauth2.Endpoint{AuthURL: "https://accounts.google.com/o/oauth2/auth"},
+	}
+	cases := []struct {
+		opts     []oauth2.AuthCodeOption
+		expected url.Values
+	}{
+		{nil, url.Values{"prompt": {"select_account"}}},
+		{
+			[]oauth2.AuthCodeOption{AccessTypeOffline, PromptConsent, IncludeGrantedScopes, LoginHint("ben@example.com")},
+			url.Values{
+				"prompt":                 {"consent"},
+				"access_type":            {"offline"},
+				"include_granted_scopes": {"true"},
+				"login_hint":             {"ben@example.com"},
+			},
+		},
+	}
+	for _, c := range cases {
+		ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+		loginHandler := LoginHandler(config, testutils.AssertFailureNotCalled(t), c.opts...)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		loginHandler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, http.StatusFound, w.Code)
+		location, err := url.Parse(w.HeaderMap.Get("Location"))
+		assert.Nil(t, err)
+		query := location.Query()
+		for name, values := range c.expected {
+			assert.Equal(t, values, query[name])
+		}
+		assert.Equal(t, "d4e5f6", query.Get("state"))
+	}
+}
+
 func TestGoogleHandler(t *testing.T) {
 	jsonData := `{"id": "900913", "name": "Ben Bitdiddle"}`
 	expectedUser := &google.Userinfoplus{Id: "900913", Name: "Ben Bitdiddle"}
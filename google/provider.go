@@ -0,0 +1,48 @@
+package google
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "google" which performs Google
+// web logins with the given config, for mounting with a gologin.Registry.
+// The opts are added to authorization requests (see LoginHandler).
+func Provider(config *oauth2.Config, opts ...oauth2.AuthCodeOption) gologin.Provider {
+	return &provider{config: config, opts: opts}
+}
+
+// DomainProvider returns a gologin.Provider named "google" which only logs in
+// users of the hosted domains (see DomainCallbackHandler).
+func DomainProvider(config *oauth2.Config, verifier oidc.Verifier, domains []string, opts ...oauth2.AuthCodeOption) gologin.Provider {
+	return &provider{config: config, verifier: verifier, domains: domains, opts: opts}
+}
+
+type provider struct {
+	config *oauth2.Config
+	// verifier is set to restrict logins to hosted domains
+	verifier oidc.Verifier
+	domains  []string
+	opts     []oauth2.AuthCodeOption
+}
+
+func (p *provider) Name() string {
+	return "google"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	if p.verifier != nil {
+		return oidc.CSRFHandler(config, DomainLoginHandler(p.config, p.domains, failure, p.opts...))
+	}
+	return CSRFHandler(config, LoginHandler(p.config, failure, p.opts...))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	if p.verifier != nil {
+		return oidc.CSRFHandler(config, DomainCallbackHandler(p.config, p.verifier, p.domains, success, failure))
+	}
+	return CSRFHandler(config, CallbackHandler(p.config, success, failure))
+}
@@ -8,6 +8,11 @@ import (
 	"github.com/dghubble/gologin/testutils"
 )
 
+const (
+	testClientID = "client_id"
+	testNonce    = "nonce_val"
+)
+
 // newGoogleTestServer returns a new httptest.Server which mocks the Google
 // Userinfoplus endpoint and a client which proxies requests to the server.
 // The server responds with the given json data. The caller must close the
@@ -20,3 +25,34 @@ func newGoogleTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	})
 	return client, server
 }
+
+// newTokeninfoTestServer returns a new httptest.Server which mocks the Google
+// Userinfoplus endpoint and the tokeninfo endpoint, which responds with the
+// given tokeninfo json data for "some-token", and a client which proxies
+// requests to the server. The caller must close the server.
+func newTokeninfoTestServer(jsonData, tokeninfoJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/oauth2/v2/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
+	})
+	mux.HandleFunc("/oauth2/v2/tokeninfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Query().Get("access_token") != "some-token" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_token", "error_description": "Invalid Value"}`)
+			return
+		}
+		fmt.Fprint(w, tokeninfoJSON)
+	})
+	return client, server
+}
+
+// testClaims are the claims of a Google ID Token for testClientID and
+// testNonce.
+var testClaims = map[string]interface{}{
+	"iss":   Issuer,
+	"aud":   testClientID,
+	"sub":   "900913",
+	"nonce": testNonce,
+}
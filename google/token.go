@@ -0,0 +1,77 @@
+package google
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+	google "google.golang.org/api/oauth2/v2"
+)
+
+// Google access token validation errors
+var (
+	ErrInvalidToken  = errors.New("google: access token is invalid")
+	ErrTokenWrongApp = errors.New("google: access token was issued to another app")
+)
+
+// TokenHandler receives a Google access token obtained by a native (e.g.
+// mobile) client and gets the corresponding Google User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are only accepted if
+// tokeninfo reports they were issued to (or for) the config ClientID. See
+// oauth2.TokenHandler for the accepted request formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = googleHandler(config, success, failure)
+	success = tokeninfoHandler(config, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
+
+// tokeninfoHandler is a http.Handler that checks with tokeninfo that the
+// OAuth2 Token from the ctx was issued to the config ClientID. If so, the
+// success handler is called. Otherwise, the failure handler is called.
+func tokeninfoHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		googleService, err := google.New(config.Client(ctx, token))
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		info, err := googleService.Tokeninfo().AccessToken(token.AccessToken).Do()
+		err = validateTokeninfo(info, err, config.ClientID)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// validateTokeninfo returns an error if the given Tokeninfo or error are
+// unexpected or the token's audience and authorized party (issued_to) are not
+// the client ID. Returns nil if the token is valid for the client.
+func validateTokeninfo(info *google.Tokeninfo, err error, clientID string) error {
+	if err != nil || info == nil {
+		return ErrInvalidToken
+	}
+	if clientID == "" || (info.Audience != clientID && info.IssuedTo != clientID) {
+		return ErrTokenWrongApp
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+	google "google.golang.org/api/oauth2/v2"
+)
+
+func TestTokenHandler(t *testing.T) {
+	jsonData := `{"id": "900913", "name": "Ben Bitdiddle"}`
+	tokeninfoJSON := `{"issued_to": "android_client_id", "audience": "client_id", "user_id": "900913", "expires_in": 3599}`
+	proxyClient, server := newTokeninfoTestServer(jsonData, tokeninfoJSON)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: testClientID}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "some-token", token.AccessToken)
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "900913", user.Id)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// TokenHandler assert that:
+	// - access token audience is checked with tokeninfo
+	// - google User is obtained from the Google API
+	// - success handler is called with the Token and User in the ctx
+	tokenHandler := TokenHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestTokenHandler_TokenWrongApp(t *testing.T) {
+	jsonData := `{"id": "900913", "name": "Ben Bitdiddle"}`
+	tokeninfoJSON := `{"issued_to": "other_client_id", "audience": "other_client_id", "user_id": "900913", "expires_in": 3599}`
+	proxyClient, server := newTokeninfoTestServer(jsonData, tokeninfoJSON)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: testClientID}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrTokenWrongApp, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler receives a token issued to another app, assert that:
+	// - failure handler is called with ErrTokenWrongApp
+	tokenHandler := TokenHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateTokeninfo(t *testing.T) {
+	assert.Equal(t, nil, validateTokeninfo(&google.Tokeninfo{Audience: "client_id"}, nil, "client_id"))
+	assert.Equal(t, nil, validateTokeninfo(&google.Tokeninfo{IssuedTo: "client_id"}, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokeninfo(&google.Tokeninfo{Audience: "client_id"}, fmt.Errorf("Server error"), "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateTokeninfo(nil, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokeninfo(&google.Tokeninfo{Audience: "other_client_id"}, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateTokeninfo(&google.Tokeninfo{}, nil, ""))
+}
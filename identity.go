@@ -0,0 +1,23 @@
+package gologin
+
+// Identity is a provider-independent description of an authenticated user.
+// Provider CallbackHandlers add an Identity to the ctx alongside their
+// provider-specific User, so applications supporting several providers may
+// read common fields without a type switch.
+type Identity struct {
+	// Provider names the identity provider (e.g. "github", "google").
+	Provider string
+	// Subject is the provider's stable identifier for the user. Subjects are
+	// unique within a Provider.
+	Subject string
+	// Email is the user's email address, if the provider shared one.
+	Email string
+	// EmailVerified is true only if the provider asserts it verified Email.
+	EmailVerified bool
+	// Name is the user's display name.
+	Name string
+	// Username is the user's handle or login, if the provider has one.
+	Username string
+	// AvatarURL is the URL of the user's profile picture.
+	AvatarURL string
+}
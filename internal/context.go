@@ -0,0 +1,45 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	cookieErrorKey key = iota
+	tempCookiesKey
+)
+
+// WithCookieError returns a copy of ctx that stores an error encountered
+// reading a temporary cookie (e.g. an invalid signature).
+func WithCookieError(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, cookieErrorKey, err)
+}
+
+// WithTempCookie returns a copy of ctx that additionally records the config
+// of a temporary cookie to be cleared once the callback consumes it.
+func WithTempCookie(ctx context.Context, config gologin.CookieConfig) context.Context {
+	configs, _ := ctx.Value(tempCookiesKey).([]gologin.CookieConfig)
+	configs = append(configs[:len(configs):len(configs)], config)
+	return context.WithValue(ctx, tempCookiesKey, configs)
+}
+
+// ClearTempCookies expires the temporary cookies recorded in the ctx.
+func ClearTempCookies(w http.ResponseWriter, ctx context.Context) {
+	configs, _ := ctx.Value(tempCookiesKey).([]gologin.CookieConfig)
+	for _, config := range configs {
+		http.SetCookie(w, ExpiredCookie(config))
+	}
+}
+
+// CookieErrorFromContext returns the error encountered reading a temporary
+// cookie or nil if there was none.
+func CookieErrorFromContext(ctx context.Context) error {
+	err, _ := ctx.Value(cookieErrorKey).(error)
+	return err
+}
@@ -12,7 +12,11 @@ import (
 //
 // The MaxAge field is used to determine whether an Expires field should be
 // added for Internet Explorer compatability and what its value should be.
+// If the CookieConfig has a Keyring, the value is signed.
 func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
+	if config.Keyring != nil {
+		value = config.Keyring.Encode(config.Name, value)
+	}
 	cookie := &http.Cookie{
 		Name:     config.Name,
 		Value:    value,
@@ -29,6 +33,30 @@ func NewCookie(config gologin.CookieConfig, value string) *http.Cookie {
 	return cookie
 }
 
+// ExpiredCookie returns a new http.Cookie which deletes the cookie named by
+// the CookieConfig.
+func ExpiredCookie(config gologin.CookieConfig) *http.Cookie {
+	config.MaxAge = -1
+	config.Keyring = nil
+	return NewCookie(config, "")
+}
+
+// CookieValue returns the value of the cookie named by the CookieConfig. If
+// the CookieConfig has a Keyring, the value must have been signed by one of
+// its keys within MaxAge seconds. Returns http.ErrNoCookie if the request has
+// no such cookie.
+func CookieValue(req *http.Request, config gologin.CookieConfig) (string, error) {
+	cookie, err := req.Cookie(config.Name)
+	if err != nil {
+		return "", err
+	}
+	if config.Keyring == nil {
+		return cookie.Value, nil
+	}
+	maxAge := time.Duration(config.MaxAge) * time.Second
+	return config.Keyring.Decode(config.Name, cookie.Value, maxAge)
+}
+
 // expiresTime converts a maxAge time in seconds to a time.Time in the future
 // if the maxAge is positive or the beginning of the epoch if maxAge is
 // negative. If maxAge is exactly 0, an empty time and false are returned
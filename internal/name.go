@@ -0,0 +1,25 @@
+package internal
+
+import (
+	"net/url"
+	"strings"
+)
+
+// InstanceName returns the provider name of a self-managed instance at the
+// base URL, as the prefix followed by "-{host}" and any path (e.g.
+// "gitlab-gitlab.example.com"). Names only contain lowercase letters,
+// digits, dots, and dashes so they are safe in URL paths and cookie names.
+func InstanceName(prefix, baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return prefix
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '-'
+	}, strings.ToLower(u.Host+strings.TrimSuffix(u.Path, "/")))
+	return prefix + "-" + name
+}
@@ -0,0 +1,98 @@
+// Package oidctest provides an ID Token signer for OpenID Connect tests.
+package oidctest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	goidc "github.com/coreos/go-oidc"
+	jose "gopkg.in/square/go-jose.v2"
+)
+
+// keyID identifies the Signer's key in ID Token headers and its key set.
+const keyID = "test"
+
+// Signer signs ID Tokens with an RSA key.
+type Signer struct {
+	key *rsa.PrivateKey
+}
+
+// NewSigner returns a Signer with a new RSA key.
+func NewSigner(t *testing.T) *Signer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Signer{key: key}
+}
+
+// KeysHandler returns a http.Handler which serves the JSON Web Key Set that
+// verifies the Signer's ID Tokens.
+func (s *Signer) KeysHandler() http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{
+			Keys: []jose.JSONWebKey{{Key: &s.key.PublicKey, KeyID: keyID, Algorithm: "RS256", Use: "sig"}},
+		})
+	}
+	return http.HandlerFunc(fn)
+}
+
+// IDToken returns an ID Token signed with the claims. Later claims override
+// earlier ones. The "iat" and "exp" claims default to now and an hour from
+// now.
+func (s *Signer) IDToken(t *testing.T, claims ...map[string]interface{}) string {
+	merged := map[string]interface{}{
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for _, c := range claims {
+		for name, value := range c {
+			merged[name] = value
+		}
+	}
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: s.key}, (&jose.SignerOptions{}).WithHeader("kid", keyID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jws, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := jws.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// KeysServer is a Signer whose JSON Web Key Set is served at "/keys".
+type KeysServer struct {
+	*Signer
+	*httptest.Server
+}
+
+// NewKeysServer returns a new KeysServer. The caller must close the server.
+func NewKeysServer(t *testing.T) *KeysServer {
+	signer := NewSigner(t)
+	mux := http.NewServeMux()
+	mux.Handle("/keys", signer.KeysHandler())
+	return &KeysServer{Signer: signer, Server: httptest.NewServer(mux)}
+}
+
+// Verifier returns a verifier of the Signer's ID Tokens from the issuer for
+// the client ID.
+func (s *KeysServer) Verifier(issuer, clientID string) *goidc.IDTokenVerifier {
+	keySet := goidc.NewRemoteKeySet(context.Background(), s.URL+"/keys")
+	return goidc.NewVerifier(issuer, keySet, &goidc.Config{ClientID: clientID})
+}
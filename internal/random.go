@@ -0,0 +1,14 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+)
+
+// RandomValue returns a base64 encoded random 32 byte string suitable for
+// non-guessable state, nonce, or verifier values.
+func RandomValue() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,112 @@
+package gologin
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envelopeVersion prefixes signed values so the format may evolve.
+const envelopeVersion = "v1"
+
+// minKeyLength is the minimum length of a Keyring key in bytes.
+const minKeyLength = 32
+
+// Keyring errors
+var (
+	ErrNoKeys        = errors.New("gologin: Keyring requires at least one key")
+	ErrShortKey      = errors.New("gologin: Keyring keys must be at least 32 bytes")
+	ErrInvalidSigned = errors.New("gologin: Invalid signed value")
+	ErrExpiredSigned = errors.New("gologin: Expired signed value")
+)
+
+// Keyring signs and verifies values with HMAC-SHA256. The first key signs new
+// values, while every key is tried when verifying. To rotate secrets, put a
+// new key first and keep the previous keys until values they signed expire.
+type Keyring struct {
+	keys [][]byte
+}
+
+// NewKeyring returns a new Keyring with the given keys, ordered from the
+// signing key to the oldest verification-only key.
+func NewKeyring(keys ...[]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+	for _, key := range keys {
+		if len(key) < minKeyLength {
+			return nil, ErrShortKey
+		}
+	}
+	return &Keyring{keys: keys}, nil
+}
+
+// Encode returns a versioned envelope of the value which records the time it
+// was issued and is signed with the first key. The name (e.g. a cookie name)
+// is covered by the signature so an envelope cannot be reused under another
+// name.
+func (k *Keyring) Encode(name, value string) string {
+	return k.encode(name, value, time.Now())
+}
+
+func (k *Keyring) encode(name, value string, issuedAt time.Time) string {
+	payload := strings.Join([]string{
+		envelopeVersion,
+		strconv.FormatInt(issuedAt.Unix(), 10),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+	}, ".")
+	mac := sign(k.keys[0], name, payload)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(mac)
+}
+
+// Decode verifies an envelope from Encode against each key and returns the
+// value. If maxAge is positive, envelopes issued more than maxAge ago are
+// rejected with ErrExpiredSigned.
+func (k *Keyring) Decode(name, envelope string, maxAge time.Duration) (string, error) {
+	parts := strings.Split(envelope, ".")
+	if len(parts) != 4 || parts[0] != envelopeVersion {
+		return "", ErrInvalidSigned
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", ErrInvalidSigned
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !k.verify(name, payload, mac) {
+		return "", ErrInvalidSigned
+	}
+	issued, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", ErrInvalidSigned
+	}
+	if maxAge > 0 && time.Since(time.Unix(issued, 0)) > maxAge {
+		return "", ErrExpiredSigned
+	}
+	value, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrInvalidSigned
+	}
+	return string(value), nil
+}
+
+// verify returns true if any key produced the mac of the named payload.
+func (k *Keyring) verify(name, payload string, mac []byte) bool {
+	for _, key := range k.keys {
+		if hmac.Equal(mac, sign(key, name, payload)) {
+			return true
+		}
+	}
+	return false
+}
+
+func sign(key []byte, name, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
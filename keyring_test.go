@@ -0,0 +1,76 @@
+package gologin
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testKey    = []byte("0123456789abcdef0123456789abcdef")
+	testOldKey = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestNewKeyring_Errors(t *testing.T) {
+	_, err := NewKeyring()
+	assert.Equal(t, ErrNoKeys, err)
+	_, err = NewKeyring(testKey, []byte("short"))
+	assert.Equal(t, ErrShortKey, err)
+}
+
+func TestKeyring_EncodeDecode(t *testing.T) {
+	keyring, err := NewKeyring(testKey)
+	assert.Nil(t, err)
+	envelope := keyring.Encode("name", "some value")
+	assert.True(t, strings.HasPrefix(envelope, "v1."))
+	assert.NotContains(t, envelope, "some value")
+	value, err := keyring.Decode("name", envelope, time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "some value", value)
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	oldKeyring, _ := NewKeyring(testOldKey)
+	keyring, _ := NewKeyring(testKey, testOldKey)
+	// values signed by a previous key still verify
+	value, err := keyring.Decode("name", oldKeyring.Encode("name", "value"), 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+	// values are signed by the first key
+	_, err = oldKeyring.Decode("name", keyring.Encode("name", "value"), 0)
+	assert.Equal(t, ErrInvalidSigned, err)
+}
+
+func TestKeyring_DecodeInvalid(t *testing.T) {
+	keyring, _ := NewKeyring(testKey)
+	envelope := keyring.Encode("name", "value")
+	parts := strings.Split(envelope, ".")
+	tampered := strings.Join([]string{parts[0], parts[1], "dGFtcGVyZWQ", parts[3]}, ".")
+	cases := []struct {
+		name     string
+		envelope string
+	}{
+		{"name", "value"},
+		{"name", "v2." + strings.Join(parts[1:], ".")},
+		{"name", tampered},
+		{"name", envelope + "x"},
+		{"other", envelope},
+	}
+	for _, c := range cases {
+		_, err := keyring.Decode(c.name, c.envelope, 0)
+		assert.Equal(t, ErrInvalidSigned, err, c.envelope)
+	}
+}
+
+func TestKeyring_DecodeExpired(t *testing.T) {
+	keyring, _ := NewKeyring(testKey)
+	envelope := keyring.encode("name", "value", time.Now().Add(-2*time.Minute))
+	_, err := keyring.Decode("name", envelope, time.Minute)
+	assert.Equal(t, ErrExpiredSigned, err)
+	// a non-positive maxAge skips the expiry check
+	value, err := keyring.Decode("name", envelope, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+}
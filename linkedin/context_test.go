@@ -8,7 +8,7 @@ import (
 )
 
 func TestContextUser(t *testing.T) {
-	expectedUser := &User{ID: "12", FirstName: "Gopher"}
+	expectedUser := &User{ID: "12", Name: "Gopher"}
 	ctx := WithUser(context.Background(), expectedUser)
 	user, err := UserFromContext(ctx)
 	assert.Equal(t, expectedUser, user)
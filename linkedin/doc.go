@@ -1,2 +1,3 @@
-// package linkedin provides Linkedin OAuth2 login and callback handlers.
+// Package linkedin provides Sign In with LinkedIn (OpenID Connect) login and
+// callback handlers.
 package linkedin
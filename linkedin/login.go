@@ -1,50 +1,104 @@
 package linkedin
 
 import (
+	"context"
 	"errors"
 	"net/http"
 
 	"github.com/dghubble/gologin"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
 	"golang.org/x/oauth2"
 )
 
+// Issuer is the Sign In with LinkedIn OpenID Connect issuer.
+const Issuer = "https://www.linkedin.com/oauth"
+
+// Endpoint is the Linkedin OAuth2 endpoint. Configs using it should request
+// the "openid", "profile", and "email" scopes.
+var Endpoint = oauth2.Endpoint{
+	AuthURL:  "https://www.linkedin.com/oauth/v2/authorization",
+	TokenURL: "https://www.linkedin.com/oauth/v2/accessToken",
+	// Linkedin requires client credentials in the token request body
+	AuthStyle: oauth2.AuthStyleInParams,
+}
+
 // Linkedin login errors
 var (
 	ErrUnableToGetLinkedinUser = errors.New("linkedin: unable to get Linkedin User")
 )
 
-// CSRFHandler checks for a state cookie. If found, the state value is read
-// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
-// and to a (short-lived) state cookie issued to the requester.
+// NewVerifier uses OpenID Connect discovery to return an oidc.Verifier of
+// Linkedin ID Tokens issued to the client ID. The ctx is retained to fetch
+// signing keys.
+func NewVerifier(ctx context.Context, clientID string) (oidc.Verifier, error) {
+	return oidc.NewVerifier(ctx, Issuer, clientID)
+}
+
+// CSRFHandler checks for state and nonce cookies. If found, the values are
+// read and added to the ctx. Otherwise, non-guessable values are added to the
+// ctx and to (short-lived) cookies issued to the requester.
 //
-// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. If you wish to issue
-// state params differently, write a http.Handler which sets the ctx state,
-// using oauth2 WithState(ctx, state) since it is required by LoginHandler
-// and CallbackHandler.
+// Implements OAuth 2 RFC 6749 10.12 CSRF Protection. Both values are required
+// by LoginHandler and CallbackHandler (see oidc.CSRFHandler).
 func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
-	return oauth2Login.CSRFHandler(config, success)
+	return oidc.CSRFHandler(config, success)
 }
 
-// LoginHandler handles Linkedin login requests by reading the state value
-// from the ctx and redirecting requests to the AuthURL with that state value.
+// LoginHandler handles Linkedin login requests by reading the state and nonce
+// values from the ctx and redirecting requests to the AuthURL with those
+// values.
 func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
-	return oauth2Login.LoginHandler(config, failure)
+	return oidc.LoginHandler(config, failure)
 }
 
 // CallbackHandler handles Linkedin redirection URI requests and adds the
-// Linkedin access token and User to the ctx. If authentication succeeds,
-// handling delegates to the success handler, otherwise to the failure
-// handler.
-func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+// Linkedin access token and User to the ctx. The ID Token is verified (see
+// oidc.IDTokenHandler) before the User is read from the userinfo endpoint,
+// and must have the User's subject. If authentication succeeds, handling
+// delegates to the success handler, otherwise to the failure handler.
+func CallbackHandler(config *oauth2.Config, verifier oidc.Verifier, success, failure http.Handler) http.Handler {
+	success = subjectHandler(success, failure)
 	success = linkedinHandler(config, success, failure)
+	success = oidc.IDTokenHandler(verifier, success, failure)
 	return oauth2Login.CallbackHandler(config, success, failure)
 }
 
+// subjectHandler is a http.Handler that checks the verified ID Token and
+// Linkedin User in the ctx have the same subject. If so, the success handler
+// is called. Otherwise, the failure handler is called.
+func subjectHandler(success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		idToken, err := oidc.IDTokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if idToken.Subject != user.ID {
+			ctx = gologin.WithError(ctx, ErrUnableToGetLinkedinUser)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
 // linkedinHandler is a http.Handler that gets the OAuth2 Token from the ctx
-// to get the corresponding Linkedin User. If successful, the user is added to
-// the ctx and the success handler is called. Otherwise, the failure handler
-// is called.
+// to get the corresponding Linkedin User. If successful, the user is added
+// to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
 func linkedinHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
@@ -59,7 +113,7 @@ func linkedinHandler(config *oauth2.Config, success, failure http.Handler) http.
 		}
 		httpClient := config.Client(ctx, token)
 		linkedinService := newClient(httpClient)
-		user, resp, err := linkedinService.People()
+		user, resp, err := linkedinService.UserInfo()
 		err = validateResponse(user, resp, err)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
@@ -67,6 +121,7 @@ func linkedinHandler(config *oauth2.Config, success, failure http.Handler) http.
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -83,3 +138,15 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Linkedin User.
+func newIdentity(user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:      "linkedin",
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		AvatarURL:     user.Picture,
+	}
+}
@@ -8,21 +8,36 @@ import (
 	"testing"
 
 	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal/oidctest"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
 	"github.com/dghubble/gologin/testutils"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/oauth2"
 )
 
 func TestLinkedinHandler(t *testing.T) {
-	jsonData := `{"id": "54638001", "firstName": "Ivy Crimson"}`
-	expectedUser := &User{ID: "54638001", FirstName: "Ivy Crimson"}
+	jsonData := `{"sub": "54638001", "name": "Ivy Crimson", "given_name": "Ivy", "family_name": "Crimson", "picture": "https://example.com/ivy.png", "email": "ivy@example.com", "email_verified": true}`
+	expectedUser := &User{
+		ID:            "54638001",
+		Name:          "Ivy Crimson",
+		GivenName:     "Ivy",
+		FamilyName:    "Crimson",
+		Picture:       "https://example.com/ivy.png",
+		Email:         "ivy@example.com",
+		EmailVerified: true,
+	}
 	proxyClient, server := newLinkedinTestServer(jsonData)
 	defer server.Close()
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
 	// oauth2 Client will use the proxy client's base Transport
 	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
-	anyToken := &oauth2.Token{AccessToken: "any-token"}
-	ctx = oauth2Login.WithToken(ctx, anyToken)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"sub": "54638001"}),
+	})
+	ctx = oauth2Login.WithToken(ctx, token)
+	ctx = oidc.WithNonce(ctx, testNonce)
 
 	config := &oauth2.Config{}
 	success := func(w http.ResponseWriter, req *http.Request) {
@@ -30,16 +45,26 @@ func TestLinkedinHandler(t *testing.T) {
 		linkedinUser, err := UserFromContext(ctx)
 		assert.Nil(t, err)
 		assert.Equal(t, expectedUser, linkedinUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{
+			Provider:      "linkedin",
+			Subject:       "54638001",
+			Email:         "ivy@example.com",
+			EmailVerified: true,
+			Name:          "Ivy Crimson",
+			AvatarURL:     "https://example.com/ivy.png",
+		}, identity)
 		fmt.Fprintf(w, "success handler called")
 	}
 	failure := testutils.AssertFailureNotCalled(t)
 
 	// LinkedinHandler assert that:
-	// - Token is read from the ctx and passed to the linkedin API
-	// - linkedin User is obtained from the linkedin API
+	// - Token is read from the ctx and its ID Token is verified
+	// - linkedin User is obtained from the linkedin userinfo endpoint
 	// - success handler is called
 	// - linkedin User is added to the ctx of the success handler
-	linkedinHandler := linkedinHandler(config, http.HandlerFunc(success), failure)
+	linkedinHandler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), linkedinHandler(config, subjectHandler(http.HandlerFunc(success), failure), failure), failure)
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("GET", "/", nil)
 	linkedinHandler.ServeHTTP(w, req.WithContext(ctx))
@@ -97,8 +122,71 @@ func TestLinkedinHandler_ErrorGettingUser(t *testing.T) {
 	assert.Equal(t, "failure handler called", w.Body.String())
 }
 
+func TestLinkedinHandler_IDToken(t *testing.T) {
+	proxyClient, server := newLinkedinTestServer(`{"sub": "54638001", "name": "Ivy Crimson"}`)
+	defer server.Close()
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	cases := []struct {
+		token    *oauth2.Token
+		expected string
+	}{
+		// missing ID Token
+		{&oauth2.Token{AccessToken: "any-token"}, oidc.ErrMissingIDToken.Error()},
+		// ID Token issued to another client
+		{(&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+			"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"sub": "54638001", "aud": "other-client"}),
+		}), "oidc: expected audience \"client_id\" got [\"other-client\"]"},
+		// ID Token of another user
+		{(&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+			"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"sub": "77777777"}),
+		}), ErrUnableToGetLinkedinUser.Error()},
+	}
+	for _, c := range cases {
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+		ctx = oauth2Login.WithToken(ctx, c.token)
+		ctx = oidc.WithNonce(ctx, testNonce)
+
+		config := &oauth2.Config{}
+		success := testutils.AssertSuccessNotCalled(t)
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			if assert.NotNil(t, err) {
+				assert.Equal(t, c.expected, err.Error())
+			}
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		linkedinHandler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), linkedinHandler(config, subjectHandler(success, http.HandlerFunc(failure)), http.HandlerFunc(failure)), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		linkedinHandler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestSubjectHandler_MissingCtxIDToken(t *testing.T) {
+	ctx := WithUser(context.Background(), &User{ID: "54638001"})
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oidc: Context missing ID Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// subjectHandler without a verified ID Token in the ctx, assert that:
+	// - failure handler is called
+	handler := subjectHandler(success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
 func TestValidateResponse(t *testing.T) {
-	validUser := &User{ID: "54638001", FirstName: "Ivy Crimson"}
+	validUser := &User{ID: "54638001", Name: "Ivy Crimson"}
 	validResponse := &http.Response{StatusCode: 200}
 	invalidResponse := &http.Response{StatusCode: 500}
 	assert.Equal(t, nil, validateResponse(validUser, validResponse, nil))
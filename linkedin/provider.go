@@ -0,0 +1,33 @@
+package linkedin
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "linkedin" which performs Linkedin
+// web logins with the given config and ID Token verifier, for mounting with a
+// gologin.Registry.
+func Provider(config *oauth2.Config, verifier oidc.Verifier) gologin.Provider {
+	return &provider{config: config, verifier: verifier}
+}
+
+type provider struct {
+	config   *oauth2.Config
+	verifier oidc.Verifier
+}
+
+func (p *provider) Name() string {
+	return "linkedin"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, CallbackHandler(p.config, p.verifier, success, failure))
+}
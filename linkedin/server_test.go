@@ -8,14 +8,55 @@ import (
 	"github.com/dghubble/gologin/testutils"
 )
 
+const (
+	testClientID = "client_id"
+	testNonce    = "nonce_val"
+)
+
 // newLinkedinTestServer returns a new httptest.Server which mocks the Linkedin
-// user endpoint and a client which proxies requests to the server. The server
-// responds with the given json data. The caller must close the server.
+// userinfo endpoint and a client which proxies requests to the server. The
+// server responds with the given json data. The caller must close the server.
 func newLinkedinTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	client, mux, server := testutils.TestServer()
-	mux.HandleFunc("/v1/people/~:(id,firstName,lastName,num-connections,email-address,picture-url)?format=json", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/v2/userinfo", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		fmt.Fprintf(w, jsonData)
 	})
 	return client, server
 }
+
+// newIntrospectTestServer returns a new httptest.Server which mocks the
+// Linkedin userinfo and token introspection endpoints and a client which
+// proxies requests to the server. The introspection endpoint responds with
+// the given introspectJSON for "some-token" and the app's client credentials.
+// The caller must close the server.
+func newIntrospectTestServer(jsonData, introspectJSON string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/v2/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, jsonData)
+	})
+	mux.HandleFunc("/oauth/v2/introspectToken", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		r.ParseForm()
+		if r.PostForm.Get("client_id") != testClientID || r.PostForm.Get("client_secret") != "client_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client"}`)
+			return
+		}
+		if r.PostForm.Get("token") != "some-token" {
+			fmt.Fprint(w, `{"active": false}`)
+			return
+		}
+		fmt.Fprint(w, introspectJSON)
+	})
+	return client, server
+}
+
+// testClaims are the claims of a Linkedin ID Token for testClientID and
+// testNonce.
+var testClaims = map[string]interface{}{
+	"iss":   Issuer,
+	"aud":   testClientID,
+	"nonce": testNonce,
+}
@@ -0,0 +1,74 @@
+package linkedin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// Linkedin access token validation errors
+var (
+	ErrInvalidToken  = errors.New("linkedin: access token is invalid")
+	ErrTokenWrongApp = errors.New("linkedin: access token was issued to another app")
+)
+
+// TokenHandler receives a Linkedin access token obtained by a native (e.g.
+// mobile) client and gets the corresponding Linkedin User. If successful, the
+// access token and User are added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Since native clients obtain tokens themselves, tokens are only accepted if
+// token introspection with the config ClientID and ClientSecret shows they
+// are active and were issued to the app. See oauth2.TokenHandler for the
+// accepted request formats.
+func TokenHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = linkedinHandler(config, success, failure)
+	success = introspectHandler(config, success, failure)
+	return oauth2Login.TokenHandler(success, failure)
+}
+
+// introspectHandler is a http.Handler that introspects the OAuth2 Token from
+// the ctx to check it is active and was issued to the config ClientID. If so,
+// the success handler is called. Otherwise, the failure handler is called.
+func introspectHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		// introspection authenticates with client credentials, not the token
+		linkedinService := newClient(oauth2.NewClient(ctx, nil))
+		info, resp, err := linkedinService.Introspect(config.ClientID, config.ClientSecret, token.AccessToken)
+		err = validateIntrospection(info, resp, err, config.ClientID)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// validateIntrospection returns an error if the given introspection, raw
+// http.Response, or error are unexpected, the token is inactive, or it was
+// issued to another app than the client ID. Returns nil if the token is valid
+// for the client.
+func validateIntrospection(info *introspection, resp *http.Response, err error, clientID string) error {
+	if err != nil || resp.StatusCode != http.StatusOK || info == nil || !info.Active {
+		return ErrInvalidToken
+	}
+	if clientID == "" || info.ClientID != clientID {
+		return ErrTokenWrongApp
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package linkedin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestTokenHandler(t *testing.T) {
+	proxyClient, server := newIntrospectTestServer(`{"sub": "782bbtaQ", "name": "Ivy Crimson"}`, `{"active": true, "client_id": "client_id", "status": "active"}`)
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: testClientID, ClientSecret: "client_secret"}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "some-token", token.AccessToken)
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &User{ID: "782bbtaQ", Name: "Ivy Crimson"}, user)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// TokenHandler assert that:
+	// - access token is introspected with the app's client credentials
+	// - linkedin User is obtained from the userinfo endpoint
+	// - success handler is called with the Token and User in the ctx
+	tokenHandler := TokenHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestTokenHandler_TokenWrongApp(t *testing.T) {
+	proxyClient, server := newIntrospectTestServer(`{"sub": "782bbtaQ"}`, `{"active": true, "client_id": "other_client_id", "status": "active"}`)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+
+	config := &oauth2.Config{ClientID: testClientID, ClientSecret: "client_secret"}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrTokenWrongApp, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TokenHandler receives a token issued to another app, assert that:
+	// - failure handler is called with ErrTokenWrongApp
+	tokenHandler := TokenHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/", nil)
+	req.Header.Set("Authorization", "Bearer some-token")
+	tokenHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateIntrospection(t *testing.T) {
+	valid := &introspection{Active: true, ClientID: "client_id"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 401}
+	assert.Equal(t, nil, validateIntrospection(valid, validResponse, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateIntrospection(valid, validResponse, fmt.Errorf("Server error"), "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateIntrospection(valid, invalidResponse, nil, "client_id"))
+	assert.Equal(t, ErrInvalidToken, validateIntrospection(&introspection{ClientID: "client_id"}, validResponse, nil, "client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateIntrospection(valid, validResponse, nil, "other_client_id"))
+	assert.Equal(t, ErrTokenWrongApp, validateIntrospection(&introspection{Active: true}, validResponse, nil, ""))
+}
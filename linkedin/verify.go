@@ -6,18 +6,40 @@ import (
 	"github.com/dghubble/sling"
 )
 
-const linkedinAPI = "https://api.linkedin.com"
+const (
+	linkedinAPI   = "https://api.linkedin.com/"
+	linkedinOAuth = "https://www.linkedin.com/oauth/v2/"
+)
 
-// User is a Linkedin user.
+// User is a Linkedin user from Sign In with LinkedIn using OpenID Connect.
 //
 // Note that user ids are unique to each app.
-// ref: https://developer.linkedin.com/docs/fields/basic-profile
+// ref: https://learn.microsoft.com/en-us/linkedin/consumer/integrations/self-serve/sign-in-with-linkedin-v2
 type User struct {
-	ID           string `json:"id"`
-	EmailAddress string `json:"emailAddress"`
-	FirstName    string `json:"firstName"`
-	LastName     string `json:"lastName"`
-	PictureURL   string `json:"pictureUrl"`
+	ID            string `json:"sub"`
+	Name          string `json:"name"`
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+	Picture       string `json:"picture"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// introspection describes a Linkedin access token.
+//
+// ref: https://learn.microsoft.com/en-us/linkedin/shared/authentication/token-introspection
+type introspection struct {
+	Active bool `json:"active"`
+	// ClientID is the client ID of the app the token was issued to.
+	ClientID  string `json:"client_id"`
+	Status    string `json:"status"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+type introspectParams struct {
+	ClientID     string `url:"client_id"`
+	ClientSecret string `url:"client_secret"`
+	Token        string `url:"token"`
 }
 
 // client is a Linkedin client for obtaining the current User.
@@ -34,13 +56,18 @@ func newClient(httpClient *http.Client) *client {
 	}
 }
 
-func (c *client) People() (*User, *http.Response, error) {
-	// API Console: https://apigee.com/console/linkedin
-	// https://api.linkedin.com/v1/people/~:(id,firstName,lastName,email-address,picture-url)?format=json
+// UserInfo gets the User from the OpenID Connect userinfo endpoint.
+func (c *client) UserInfo() (*User, *http.Response, error) {
 	user := new(User)
-	// Linkedin returns JSON as Content-Type text/javascript :(
-	// Set Accept header to receive proper Content-Type application/json
-	// so Sling will decode into the struct
-	resp, err := c.sling.New().Set("Accept", "application/json").Get("/v1/people/~:(id,firstName,lastName,email-address,picture-url)?format=json").ReceiveSuccess(user)
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("v2/userinfo").ReceiveSuccess(user)
 	return user, resp, err
 }
+
+// Introspect gets the introspection of the access token, authenticating as the
+// app with its client credentials.
+func (c *client) Introspect(clientID, clientSecret, accessToken string) (*introspection, *http.Response, error) {
+	info := new(introspection)
+	params := &introspectParams{ClientID: clientID, ClientSecret: clientSecret, Token: accessToken}
+	resp, err := sling.New().Client(c.c).Base(linkedinOAuth).Post("introspectToken").BodyForm(params).ReceiveSuccess(info)
+	return info, resp, err
+}
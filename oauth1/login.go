@@ -82,13 +82,13 @@ func CookieTempHandler(config gologin.CookieConfig, success, failure http.Handle
 			return
 		}
 		// read request secret from the short-lived cookie to add to ctx
-		cookie, err := req.Cookie(config.Name)
+		requestSecret, err = internal.CookieValue(req, config)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
-		ctx = WithRequestToken(ctx, "", cookie.Value)
+		ctx = WithRequestToken(ctx, "", requestSecret)
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
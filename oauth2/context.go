@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/dghubble/gologin"
 	"golang.org/x/oauth2"
 )
 
@@ -13,6 +14,9 @@ type key int
 const (
 	tokenKey key = iota
 	stateKey
+	verifierKey
+	verifierCookieKey
+	replayCacheKey
 )
 
 // WithState returns a copy of ctx that stores the state value.
@@ -29,6 +33,44 @@ func StateFromContext(ctx context.Context) (string, error) {
 	return state, nil
 }
 
+// WithVerifier returns a copy of ctx that stores the PKCE code verifier.
+func WithVerifier(ctx context.Context, verifier string) context.Context {
+	return context.WithValue(ctx, verifierKey, verifier)
+}
+
+// VerifierFromContext returns the PKCE code verifier from the ctx.
+func VerifierFromContext(ctx context.Context) (string, error) {
+	verifier, ok := ctx.Value(verifierKey).(string)
+	if !ok {
+		return "", fmt.Errorf("oauth2: Context missing code verifier")
+	}
+	return verifier, nil
+}
+
+// withVerifierCookie returns a copy of ctx that stores the code verifier
+// CookieConfig, which marks PKCE as enabled.
+func withVerifierCookie(ctx context.Context, config gologin.CookieConfig) context.Context {
+	return context.WithValue(ctx, verifierCookieKey, config)
+}
+
+// verifierCookieFromContext returns the code verifier CookieConfig from the
+// ctx, if PKCE is enabled.
+func verifierCookieFromContext(ctx context.Context) (gologin.CookieConfig, bool) {
+	config, ok := ctx.Value(verifierCookieKey).(gologin.CookieConfig)
+	return config, ok
+}
+
+// withReplayCache returns a copy of ctx that stores the ReplayCache.
+func withReplayCache(ctx context.Context, cache ReplayCache) context.Context {
+	return context.WithValue(ctx, replayCacheKey, cache)
+}
+
+// replayCacheFromContext returns the ReplayCache from the ctx, if any.
+func replayCacheFromContext(ctx context.Context) (ReplayCache, bool) {
+	cache, ok := ctx.Value(replayCacheKey).(ReplayCache)
+	return cache, ok
+}
+
 // WithToken returns a copy of ctx that stores the Token.
 func WithToken(ctx context.Context, token *oauth2.Token) context.Context {
 	return context.WithValue(ctx, tokenKey, token)
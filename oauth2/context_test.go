@@ -24,6 +24,22 @@ func TestContext_MissingState(t *testing.T) {
 	}
 }
 
+func TestContext_Verifier(t *testing.T) {
+	expectedVerifier := "verifier"
+	ctx := WithVerifier(context.Background(), expectedVerifier)
+	verifier, err := VerifierFromContext(ctx)
+	assert.Equal(t, expectedVerifier, verifier)
+	assert.Nil(t, err)
+}
+
+func TestContext_MissingVerifier(t *testing.T) {
+	verifier, err := VerifierFromContext(context.Background())
+	assert.Equal(t, "", verifier)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oauth2: Context missing code verifier", err.Error())
+	}
+}
+
 func TestContext_Token(t *testing.T) {
 	expectedToken := &oauth2.Token{AccessToken: "access_token"}
 	ctx := WithToken(context.Background(), expectedToken)
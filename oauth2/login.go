@@ -1,10 +1,10 @@
 package oauth2
 
 import (
-	"crypto/rand"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"github.com/dghubble/gologin"
@@ -14,7 +14,8 @@ import (
 
 // Errors which may occur on login.
 var (
-	ErrInvalidState = errors.New("oauth2: Invalid OAuth2 state parameter")
+	ErrInvalidState    = errors.New("oauth2: Invalid OAuth2 state parameter")
+	ErrMissingVerifier = errors.New("oauth2: Missing PKCE code verifier cookie")
 )
 
 // CSRFHandler checks for a state cookie. If found, the state value is read
@@ -25,19 +26,32 @@ var (
 // state params differently, write a http.Handler which sets the ctx state,
 // using oauth2 WithState(ctx, state) since it is required by LoginHandler
 // and CallbackHandler.
+//
+// A PKCE code verifier is kept alongside the state in a cookie named after
+// the state cookie with a "-verifier" suffix, unless the CookieConfig sets
+// DisablePKCE.
+//
+// If the CookieConfig has a Keyring, cookies are signed. A state cookie which
+// fails verification is replaced, and the error is reported by a subsequent
+// CallbackHandler.
 func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	success = pkceHandler(config, success)
 	fn := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
-		cookie, err := req.Cookie(config.Name)
+		val, err := internal.CookieValue(req, config)
 		if err == nil {
 			// add the cookie state to the ctx
-			ctx = WithState(ctx, cookie.Value)
+			ctx = WithState(ctx, val)
 		} else {
+			if err != http.ErrNoCookie {
+				ctx = internal.WithCookieError(ctx, err)
+			}
 			// add Cookie with a random state
-			val := randomState()
+			val := internal.RandomValue()
 			http.SetCookie(w, internal.NewCookie(config, val))
 			ctx = WithState(ctx, val)
 		}
+		ctx = internal.WithTempCookie(ctx, config)
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -55,9 +69,15 @@ func (s *Oauth2State) Encode() string {
 	return base64.URLEncoding.EncodeToString(data)
 }
 
-func (s *Oauth2State) Decode(state string) {
-	data, _ := base64.URLEncoding.DecodeString(state)
-	json.Unmarshal(data, s)
+func (s *Oauth2State) Decode(state string) error {
+	data, err := base64.URLEncoding.DecodeString(state)
+	if err != nil {
+		return fmt.Errorf("oauth2: Unable to decode OAuth2 state: %v", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return fmt.Errorf("oauth2: Unable to decode OAuth2 state: %v", err)
+	}
+	return nil
 }
 
 const (
@@ -73,16 +93,28 @@ const (
 // state params differently, write a ContextHandler which sets the ctx state,
 // using oauth2 WithState(ctx, state) since it is required by LoginHandler
 // and CallbackHandler.
+//
+// A PKCE code verifier is kept alongside the state in a cookie named after
+// the state cookie with a "-verifier" suffix, unless the CookieConfig sets
+// DisablePKCE.
+//
+// If the CookieConfig has a Keyring, cookies are signed. A state cookie which
+// fails verification is replaced, and the error is reported by a subsequent
+// CallbackHandler.
 func HostedLoginHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	success = pkceHandler(config, success)
 	fn := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
-		cookie, err := req.Cookie(config.Name)
+		val, err := internal.CookieValue(req, config)
 		if err == nil {
 			// add the cookie state to the ctx
-			ctx = WithState(ctx, cookie.Value)
+			ctx = WithState(ctx, val)
 		} else {
+			if err != http.ErrNoCookie {
+				ctx = internal.WithCookieError(ctx, err)
+			}
 			state := Oauth2State{
-				CSRF:       randomState(),
+				CSRF:       internal.RandomValue(),
 				RedirectTo: req.URL.Query().Get(KeyRedirectTo),
 				Transport:  req.URL.Query().Get(KeyTransport),
 			}
@@ -90,13 +122,17 @@ func HostedLoginHandler(config gologin.CookieConfig, success http.Handler) http.
 			http.SetCookie(w, internal.NewCookie(config, val))
 			ctx = WithState(ctx, val)
 		}
+		ctx = internal.WithTempCookie(ctx, config)
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
 }
 
 // LoginHandler handles OAuth2 login requests by reading the state value from
-// the ctx and redirecting requests to the AuthURL with that state value.
+// the ctx and redirecting requests to the AuthURL with that state value. If
+// PKCE is enabled (see PKCEHandler) or the ctx has a code verifier, its S256
+// code challenge is sent too. A verifier is only issued here, when a login
+// starts.
 func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
@@ -109,7 +145,11 @@ func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.Au
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
-		authURL := config.AuthCodeURL(state, opts...)
+		authOpts := opts
+		if verifier, ok := loginVerifier(w, ctx); ok {
+			authOpts = append(challengeOptions(verifier), opts...)
+		}
+		authURL := config.AuthCodeURL(state, authOpts...)
 		http.Redirect(w, req, authURL, http.StatusFound)
 	}
 	return http.HandlerFunc(fn)
@@ -117,19 +157,32 @@ func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.Au
 
 // CallbackHandler handles OAuth2 redirection URI requests by parsing the auth
 // code and state, comparing with the state value from the ctx, and obtaining
-// an OAuth2 Token.
+// an OAuth2 Token. If the ctx has a PKCE code verifier, it is sent with the
+// auth code exchange. If PKCE is enabled but the verifier cookie is missing,
+// ErrMissingVerifier is passed to the failure handler, as is any state
+// cookie an upstream handler could not verify.
+//
+// The state is used up by the callback: temporary cookies issued by upstream
+// handlers (e.g. CSRFHandler) are cleared whether or not the callback
+// succeeds, and a ReplayCache added by ReplayHandler rejects reuse.
 func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
 	if failure == nil {
 		failure = gologin.DefaultFailureHandler
 	}
 	fn := func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
+		internal.ClearTempCookies(w, ctx)
 		authCode, state, err := parseCallback(req)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		if err := internal.CookieErrorFromContext(ctx); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
 		ownerState, err := StateFromContext(ctx)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
@@ -141,8 +194,25 @@ func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
+		if cache, ok := replayCacheFromContext(ctx); ok {
+			if err := cache.Consume(state); err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(w, req.WithContext(ctx))
+				return
+			}
+		}
+		verifier, ok, err := callbackVerifier(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
 		// use the authorization code to get a Token
-		token, err := config.Exchange(ctx, authCode)
+		var exchangeOpts []oauth2.AuthCodeOption
+		if ok {
+			exchangeOpts = append(exchangeOpts, verifierOption(verifier))
+		}
+		token, err := config.Exchange(ctx, authCode, exchangeOpts...)
 		if err != nil {
 			ctx = gologin.WithError(ctx, err)
 			failure.ServeHTTP(w, req.WithContext(ctx))
@@ -154,13 +224,6 @@ func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.
 	return http.HandlerFunc(fn)
 }
 
-// Returns a base64 encoded random 32 byte string.
-func randomState() string {
-	b := make([]byte, 32)
-	rand.Read(b)
-	return base64.RawURLEncoding.EncodeToString(b)
-}
-
 // parseCallback parses the "code" and "state" parameters from the http.Request
 // and returns them.
 func parseCallback(req *http.Request) (authCode, state string, err error) {
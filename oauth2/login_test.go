@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
 
@@ -14,6 +15,168 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// CSRFHandler
+
+func TestCSRFHandler(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		state, err := StateFromContext(ctx)
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", state)
+		_, err = VerifierFromContext(ctx)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CSRFHandler without cookies, assert that:
+	// - a state cookie is issued and the state is added to the ctx of the
+	//   success handler
+	// - no code verifier is issued, since only LoginHandler issues one
+	handler := CSRFHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, config.Name, cookies[0].Name)
+	}
+}
+
+func TestCSRFHandler_ExistingCookies(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		state, _ := StateFromContext(ctx)
+		assert.Equal(t, "cookie_state", state)
+		verifier, _ := VerifierFromContext(ctx)
+		assert.Equal(t, "cookie_verifier", verifier)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CSRFHandler with state and code verifier cookies, assert that:
+	// - cookie values are added to the ctx of the success handler
+	// - no new cookies are issued
+	handler := CSRFHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: config.Name, Value: "cookie_state"})
+	req.AddCookie(&http.Cookie{Name: config.Name + "-verifier", Value: "cookie_verifier"})
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 0)
+}
+
+func TestCSRFHandler_DisablePKCE(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	config.DisablePKCE = true
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		state, err := StateFromContext(ctx)
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", state)
+		_, err = VerifierFromContext(ctx)
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CSRFHandler with PKCE disabled, assert that:
+	// - only the state cookie is issued
+	// - no code verifier is added to the ctx of the success handler
+	handler := CSRFHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, config.Name, cookies[0].Name)
+	}
+}
+
+func TestHostedLoginHandler_DisablePKCE(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	config.DisablePKCE = true
+	success := func(w http.ResponseWriter, req *http.Request) {
+		_, err := VerifierFromContext(req.Context())
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// HostedLoginHandler with PKCE disabled, assert that:
+	// - only the state cookie is issued
+	handler := HostedLoginHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 1)
+}
+
+func TestCSRFHandler_SignedCookies(t *testing.T) {
+	keyring, _ := gologin.NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	config := gologin.DebugOnlyCookieConfig
+	config.Keyring = keyring
+	var issuedState string
+	login := func(w http.ResponseWriter, req *http.Request) {
+		issuedState, _ = StateFromContext(req.Context())
+	}
+
+	// CSRFHandler with a Keyring, assert that:
+	// - the state cookie value is signed
+	handler := CSRFHandler(config, http.HandlerFunc(login))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.NotEqual(t, issuedState, cookies[0].Value)
+	}
+
+	callback := func(w http.ResponseWriter, req *http.Request) {
+		state, err := StateFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, issuedState, state)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// CSRFHandler with signed cookies, assert that:
+	// - the verified state is added to the ctx of the success handler
+	handler = CSRFHandler(config, http.HandlerFunc(callback))
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/", nil)
+	for _, cookie := range cookies {
+		req.AddCookie(cookie)
+	}
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCSRFHandler_InvalidSignedCookie(t *testing.T) {
+	keyring, _ := gologin.NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	config := gologin.DebugOnlyCookieConfig
+	config.Keyring = keyring
+	oauth2Config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, gologin.ErrInvalidSigned, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CSRFHandler with a forged state cookie, chained to CallbackHandler,
+	// assert that:
+	// - failure handler is called
+	// - error about the invalid cookie is added to the ctx
+	handler := CSRFHandler(config, CallbackHandler(oauth2Config, success, http.HandlerFunc(failure)))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=forged", nil)
+	req.AddCookie(&http.Cookie{Name: config.Name, Value: "forged"})
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
 // LoginHandler
 
 func TestLoginHandler(t *testing.T) {
@@ -42,6 +205,65 @@ func TestLoginHandler(t *testing.T) {
 	assert.Equal(t, expectedRedirect, w.HeaderMap.Get("Location"))
 }
 
+func TestLoginHandler_PKCE(t *testing.T) {
+	// RFC 7636 Appendix B example verifier and S256 challenge
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	expectedChallenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// LoginHandler with a ctx code verifier, assert that:
+	// - redirect url includes the S256 code challenge of the verifier
+	loginHandler := LoginHandler(config, failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithState(context.Background(), "state_val")
+	ctx = WithVerifier(ctx, verifier)
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, expectedChallenge, location.Query().Get("code_challenge"))
+		assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+		assert.Equal(t, "", location.Query().Get("code_verifier"))
+	}
+}
+
+func TestLoginHandler_IssuesVerifier(t *testing.T) {
+	stateConfig := gologin.DebugOnlyCookieConfig
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://api.example.com/authorize",
+		},
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CSRFHandler chained to LoginHandler without cookies, assert that:
+	// - state and code verifier cookies are issued
+	// - redirect url includes the S256 code challenge of the issued verifier
+	handler := CSRFHandler(stateConfig, LoginHandler(config, failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, stateConfig.Name, cookies[0].Name)
+		assert.Equal(t, stateConfig.Name+"-verifier", cookies[1].Name)
+		location, err := url.Parse(w.HeaderMap.Get("Location"))
+		if assert.Nil(t, err) {
+			expected := challengeOptions(cookies[1].Value)
+			assert.Equal(t, config.AuthCodeURL(cookies[0].Value, expected...), location.String())
+		}
+	}
+}
+
 func TestLoginHandler_MissingCtxState(t *testing.T) {
 	config := &oauth2.Config{}
 	failure := func(w http.ResponseWriter, req *http.Request) {
@@ -109,6 +331,95 @@ func TestCallbackHandler(t *testing.T) {
 	assert.Equal(t, "success handler called", w.Body.String())
 }
 
+func TestCallbackHandler_PKCE(t *testing.T) {
+	server := NewTestServerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		assert.Equal(t, "verifier_val", req.PostForm.Get("code_verifier"))
+		w.Header().Set(contentType, jsonContentType)
+		w.Write([]byte(`{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`))
+	})
+	defer server.Close()
+
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// CallbackHandler with a ctx code verifier, assert that:
+	// - the code verifier is sent with the auth code exchange
+	callbackHandler := CallbackHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	ctx := WithState(context.Background(), "d4e5f6")
+	ctx = WithVerifier(ctx, "verifier_val")
+	callbackHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestCallbackHandler_ClearsTempCookies(t *testing.T) {
+	_, server := testutils.NewErrorServer("OAuth2 Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	stateConfig := gologin.DebugOnlyCookieConfig
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CSRFHandler chained to a failing CallbackHandler, assert that:
+	// - state and code verifier cookies are cleared
+	handler := CSRFHandler(stateConfig, CallbackHandler(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure)))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	req.AddCookie(&http.Cookie{Name: stateConfig.Name, Value: "d4e5f6"})
+	req.AddCookie(&http.Cookie{Name: stateConfig.Name + "-verifier", Value: "verifier_val"})
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 2) {
+		assert.Equal(t, stateConfig.Name, cookies[0].Name)
+		assert.Equal(t, stateConfig.Name+"-verifier", cookies[1].Name)
+		for _, cookie := range cookies {
+			assert.Equal(t, "", cookie.Value)
+			assert.Equal(t, -1, cookie.MaxAge)
+		}
+	}
+}
+
+func TestCallbackHandler_MissingVerifier(t *testing.T) {
+	stateConfig := gologin.DebugOnlyCookieConfig
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrMissingVerifier, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// CSRFHandler chained to CallbackHandler without a code verifier cookie,
+	// assert that:
+	// - failure handler is called with ErrMissingVerifier, rather than
+	//   exchanging the auth code with a made up verifier
+	// - no code verifier cookie is issued
+	handler := CSRFHandler(stateConfig, CallbackHandler(config, success, http.HandlerFunc(failure)))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	req.AddCookie(&http.Cookie{Name: stateConfig.Name, Value: "d4e5f6"})
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+	for _, cookie := range w.Result().Cookies() {
+		assert.Equal(t, "", cookie.Value)
+	}
+}
+
 func TestCallbackHandler_ParseCallbackError(t *testing.T) {
 	config := &oauth2.Config{}
 	success := testutils.AssertSuccessNotCalled(t)
@@ -0,0 +1,100 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// PKCEHandler checks for a code verifier cookie. If found, the verifier is
+// read and added to the ctx. Otherwise, LoginHandler adds a non-guessable
+// verifier to a (short-lived) cookie issued to the requester, while
+// CallbackHandler fails with ErrMissingVerifier, since the verifier whose
+// challenge was sent at login is lost.
+//
+// Implements OAuth 2 RFC 7636 Proof Key for Code Exchange. LoginHandler sends
+// the S256 code challenge of the verifier and CallbackHandler sends the
+// verifier when exchanging the auth code. CSRFHandler and HostedLoginHandler
+// chain a PKCEHandler for you, unless the CookieConfig sets DisablePKCE.
+func PKCEHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		val, err := internal.CookieValue(req, config)
+		if err == nil {
+			// add the cookie verifier to the ctx
+			ctx = WithVerifier(ctx, val)
+		} else if err != http.ErrNoCookie {
+			ctx = internal.WithCookieError(ctx, err)
+		}
+		ctx = withVerifierCookie(ctx, config)
+		ctx = internal.WithTempCookie(ctx, config)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// pkceHandler chains a PKCEHandler, whose cookie is named after the state
+// cookie, unless the state CookieConfig disables PKCE.
+func pkceHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	if config.DisablePKCE {
+		return success
+	}
+	return PKCEHandler(verifierCookieConfig(config), success)
+}
+
+// loginVerifier returns the ctx code verifier. If PKCE is enabled and the ctx
+// has none, a new verifier is issued in a cookie. Returns false if PKCE is
+// not used.
+func loginVerifier(w http.ResponseWriter, ctx context.Context) (string, bool) {
+	if verifier, err := VerifierFromContext(ctx); err == nil {
+		return verifier, true
+	}
+	config, ok := verifierCookieFromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	// add Cookie with a random verifier
+	verifier := internal.RandomValue()
+	http.SetCookie(w, internal.NewCookie(config, verifier))
+	return verifier, true
+}
+
+// callbackVerifier returns the ctx code verifier. Returns ErrMissingVerifier
+// if PKCE is enabled but the ctx has none, or false if PKCE is not used.
+func callbackVerifier(ctx context.Context) (string, bool, error) {
+	if verifier, err := VerifierFromContext(ctx); err == nil {
+		return verifier, true, nil
+	}
+	if _, ok := verifierCookieFromContext(ctx); ok {
+		return "", false, ErrMissingVerifier
+	}
+	return "", false, nil
+}
+
+// challengeOptions returns the AuthCodeOptions which send the S256 code
+// challenge of the verifier.
+func challengeOptions(verifier string) []oauth2.AuthCodeOption {
+	sum := sha256.Sum256([]byte(verifier))
+	return []oauth2.AuthCodeOption{
+		oauth2.SetAuthURLParam("code_challenge", base64.RawURLEncoding.EncodeToString(sum[:])),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	}
+}
+
+// verifierOption returns the AuthCodeOption which sends the code verifier
+// with an auth code exchange.
+func verifierOption(verifier string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("code_verifier", verifier)
+}
+
+// verifierCookieConfig returns a copy of the state CookieConfig which names
+// the code verifier cookie.
+func verifierCookieConfig(config gologin.CookieConfig) gologin.CookieConfig {
+	config.Name = config.Name + "-verifier"
+	return config
+}
@@ -0,0 +1,210 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dghubble/gologin"
+)
+
+// Transports for handing values to the frontend named by the Oauth2State
+// Transport field.
+const (
+	// TransportHash sends values in the URL fragment (default).
+	TransportHash = "hash"
+	// TransportQuery sends values in the URL query string.
+	TransportQuery = "query"
+	// TransportDeepLink sends values in the query string of a custom-scheme
+	// URL (e.g. myapp://login) which opens a mobile app.
+	TransportDeepLink = "deeplink"
+)
+
+// Errors which may occur handing off a hosted login.
+var (
+	ErrMissingRedirect      = errors.New("oauth2: OAuth2 state missing redirectTo")
+	ErrRedirectNotAllowed   = errors.New("oauth2: redirectTo is not an allowed redirect")
+	ErrUnsupportedTransport = errors.New("oauth2: Unsupported transport")
+)
+
+// RedirectPolicy allowlists the redirectTo targets of hosted logins to
+// prevent open redirects.
+type RedirectPolicy struct {
+	// Origins are the allowed scheme and host pairs, such as
+	// "https://app.example.com" or "myapp://login".
+	Origins []string
+	// Schemes are the allowed private-use URI schemes (RFC 8252), such as
+	// "com.example.app", whose redirect URIs have no host (e.g.
+	// "com.example.app:/oauth2redirect"). These are compared by scheme and
+	// path only.
+	Schemes []string
+	// PathPrefixes optionally restricts redirect paths to those under one of
+	// the prefixes, such as "/auth/". Any path is allowed when empty.
+	PathPrefixes []string
+}
+
+// Validate parses the redirect target and returns it if its origin (or
+// private-use scheme, for hostless URIs) and path are allowed by the policy.
+// Otherwise, ErrRedirectNotAllowed is returned.
+func (p RedirectPolicy) Validate(target string) (*url.URL, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || u.Opaque != "" || u.User != nil {
+		return nil, ErrRedirectNotAllowed
+	}
+	allowed := p.allowOrigin(u)
+	if u.Host == "" {
+		allowed = p.allowScheme(u)
+	}
+	if !allowed || !p.allowPath(u) {
+		return nil, ErrRedirectNotAllowed
+	}
+	return u, nil
+}
+
+func (p RedirectPolicy) allowOrigin(u *url.URL) bool {
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+	for _, allowed := range p.Origins {
+		if strings.ToLower(strings.TrimSuffix(allowed, "/")) == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RedirectPolicy) allowScheme(u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	// web redirects always need a host
+	if scheme == "http" || scheme == "https" {
+		return false
+	}
+	for _, allowed := range p.Schemes {
+		if strings.ToLower(strings.TrimSuffix(allowed, ":")) == scheme {
+			return true
+		}
+	}
+	return false
+}
+
+func (p RedirectPolicy) allowPath(u *url.URL) bool {
+	if len(p.PathPrefixes) == 0 {
+		return true
+	}
+	// compare the cleaned path so dot segments cannot escape a prefix
+	clean := path.Clean("/" + u.Path)
+	for _, prefix := range p.PathPrefixes {
+		dir := strings.TrimSuffix(prefix, "/")
+		if clean == dir || strings.HasPrefix(clean, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamsFunc returns the values (e.g. a token or session) handed to the
+// frontend once a hosted login succeeds.
+type ParamsFunc func(req *http.Request) (url.Values, error)
+
+// TokenParams is a ParamsFunc which hands off the OAuth2 Token from the ctx
+// as access_token, token_type, and expires_in values.
+func TokenParams(req *http.Request) (url.Values, error) {
+	token, err := TokenFromContext(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	values.Set("access_token", token.AccessToken)
+	values.Set("token_type", token.Type())
+	if !token.Expiry.IsZero() {
+		values.Set("expires_in", strconv.FormatInt(int64(token.Expiry.Sub(time.Now()).Seconds()), 10))
+	}
+	return values, nil
+}
+
+// HostedSuccessHandler completes logins started by HostedLoginHandler. It
+// decodes the Oauth2State from the ctx state (verified by CallbackHandler),
+// validates its redirectTo against the policy, and redirects to it with the
+// values from params sent using the requested transport. If params is nil,
+// TokenParams is used.
+//
+// Chain it as the success handler of a CallbackHandler. On errors, handling
+// delegates to the failure handler.
+func HostedSuccessHandler(policy RedirectPolicy, params ParamsFunc, failure http.Handler) http.Handler {
+	if params == nil {
+		params = TokenParams
+	}
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		state, err := StateFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		var hosted Oauth2State
+		if err := hosted.Decode(state); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if hosted.RedirectTo == "" {
+			ctx = gologin.WithError(ctx, ErrMissingRedirect)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		target, err := policy.Validate(hosted.RedirectTo)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		values, err := params(req)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		location, err := applyTransport(target, hosted.Transport, values)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		http.Redirect(w, req, location, http.StatusFound)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// applyTransport returns the target URL with the values added as the
+// transport requires.
+func applyTransport(target *url.URL, transport string, values url.Values) (string, error) {
+	switch transport {
+	case "", TransportHash:
+		target.Fragment = ""
+		return target.String() + "#" + values.Encode(), nil
+	case TransportQuery:
+		target.RawQuery = mergeQuery(target.Query(), values).Encode()
+		return target.String(), nil
+	case TransportDeepLink:
+		if target.Scheme == "http" || target.Scheme == "https" {
+			return "", ErrUnsupportedTransport
+		}
+		target.RawQuery = mergeQuery(target.Query(), values).Encode()
+		return target.String(), nil
+	}
+	return "", ErrUnsupportedTransport
+}
+
+// mergeQuery sets the values onto the query, replacing existing keys.
+func mergeQuery(query, values url.Values) url.Values {
+	for key, vals := range values {
+		query[key] = vals
+	}
+	return query
+}
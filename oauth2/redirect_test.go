@@ -0,0 +1,162 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+var testPolicy = RedirectPolicy{
+	Origins:      []string{"https://app.example.com", "myapp://login"},
+	Schemes:      []string{"com.example.app", "https"},
+	PathPrefixes: []string{"/auth/"},
+}
+
+func TestRedirectPolicy_Validate(t *testing.T) {
+	cases := []struct {
+		target  string
+		allowed bool
+	}{
+		{"https://app.example.com/auth/done", true},
+		{"https://APP.example.com/auth", true},
+		{"myapp://login/auth/done", true},
+		{"com.example.app:/auth/done", true},
+		{"COM.example.app:/auth/done", true},
+		{"com.example.app:///auth/done", true},
+		{"https://evil.example.com/auth/done", false},
+		{"http://app.example.com/auth/done", false},
+		{"https://app.example.com/admin", false},
+		{"https://app.example.com/authority", false},
+		{"https://app.example.com/auth/../admin", false},
+		{"https://app.example.com@evil.example.com/auth/", false},
+		{"//app.example.com/auth/done", false},
+		{"/auth/done", false},
+		{"com.example.app:/admin", false},
+		{"com.example.app:auth/done", false},
+		{"com.other.app:/auth/done", false},
+		{"myapp:/auth/done", false},
+		{"https:/auth/done", false},
+	}
+	for _, c := range cases {
+		_, err := testPolicy.Validate(c.target)
+		if c.allowed {
+			assert.Nil(t, err, c.target)
+		} else {
+			assert.Equal(t, ErrRedirectNotAllowed, err, c.target)
+		}
+	}
+}
+
+func TestHostedSuccessHandler(t *testing.T) {
+	cases := []struct {
+		state    Oauth2State
+		location string
+	}{
+		{
+			Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/done", Transport: TransportHash},
+			"https://app.example.com/auth/done#access_token=any-token&token_type=Bearer",
+		},
+		{
+			Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/done?a=b"},
+			"https://app.example.com/auth/done?a=b#access_token=any-token&token_type=Bearer",
+		},
+		{
+			Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/done?a=b", Transport: TransportQuery},
+			"https://app.example.com/auth/done?a=b&access_token=any-token&token_type=Bearer",
+		},
+		{
+			Oauth2State{CSRF: "csrf", RedirectTo: "myapp://login/auth/done", Transport: TransportDeepLink},
+			"myapp://login/auth/done?access_token=any-token&token_type=Bearer",
+		},
+		{
+			Oauth2State{CSRF: "csrf", RedirectTo: "com.example.app:/auth/done", Transport: TransportDeepLink},
+			"com.example.app:/auth/done?access_token=any-token&token_type=Bearer",
+		},
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+	handler := HostedSuccessHandler(testPolicy, nil, failure)
+	for _, c := range cases {
+		// HostedSuccessHandler assert that:
+		// - redirects to the Oauth2State redirectTo
+		// - token values are sent using the Oauth2State transport
+		ctx := WithState(context.Background(), c.state.Encode())
+		ctx = WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, http.StatusFound, w.Code)
+		assert.Equal(t, c.location, w.HeaderMap.Get("Location"))
+	}
+}
+
+func TestHostedSuccessHandler_CustomParams(t *testing.T) {
+	params := func(req *http.Request) (url.Values, error) {
+		return url.Values{"session": {"abc"}}, nil
+	}
+	state := Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/done", Transport: TransportQuery}
+	handler := HostedSuccessHandler(testPolicy, params, testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithState(context.Background(), state.Encode())
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "https://app.example.com/auth/done?session=abc", w.HeaderMap.Get("Location"))
+}
+
+func TestHostedSuccessHandler_DecodeError(t *testing.T) {
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), "oauth2: Unable to decode OAuth2 state")
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// HostedSuccessHandler with a state which is not an Oauth2State, assert
+	// that:
+	// - failure handler is called with the decode error
+	handler := HostedSuccessHandler(testPolicy, nil, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := WithState(context.Background(), "not*base64")
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestHostedSuccessHandler_Errors(t *testing.T) {
+	cases := []struct {
+		state Oauth2State
+		err   error
+	}{
+		{Oauth2State{CSRF: "csrf"}, ErrMissingRedirect},
+		{Oauth2State{CSRF: "csrf", RedirectTo: "https://evil.example.com/auth/"}, ErrRedirectNotAllowed},
+		{Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/", Transport: "smoke"}, ErrUnsupportedTransport},
+		{Oauth2State{CSRF: "csrf", RedirectTo: "https://app.example.com/auth/", Transport: TransportDeepLink}, ErrUnsupportedTransport},
+	}
+	for _, c := range cases {
+		expected := c.err
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			assert.Equal(t, expected, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+
+		// HostedSuccessHandler with an invalid Oauth2State, assert that:
+		// - failure handler is called
+		// - error is added to the failure handler ctx
+		handler := HostedSuccessHandler(testPolicy, nil, http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		ctx := WithState(context.Background(), c.state.Encode())
+		ctx = WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
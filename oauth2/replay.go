@@ -0,0 +1,66 @@
+package oauth2
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrStateReused is returned when a callback presents a state value which a
+// previous callback already consumed.
+var ErrStateReused = errors.New("oauth2: OAuth2 state parameter already used")
+
+// ReplayCache records consumed state values so each can be used only once.
+type ReplayCache interface {
+	// Consume records the state value. It returns ErrStateReused if the
+	// state was already consumed.
+	Consume(state string) error
+}
+
+// ReplayHandler adds the ReplayCache to the ctx. A subsequent CallbackHandler
+// consumes the verified state from the cache and calls its failure handler
+// with ErrStateReused if the state was used before.
+func ReplayHandler(cache ReplayCache, success http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := withReplayCache(req.Context(), cache)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// MemoryReplayCache is an in-memory ReplayCache which remembers states for a
+// TTL. Replicas behind a load balancer should share a ReplayCache backed by
+// an external store instead.
+type MemoryReplayCache struct {
+	ttl    time.Duration
+	mu     sync.Mutex
+	states map[string]time.Time
+}
+
+// NewMemoryReplayCache returns a new MemoryReplayCache which remembers states
+// for the ttl. The ttl should be at least the state cookie MaxAge.
+func NewMemoryReplayCache(ttl time.Duration) *MemoryReplayCache {
+	return &MemoryReplayCache{
+		ttl:    ttl,
+		states: make(map[string]time.Time),
+	}
+}
+
+// Consume records the state value, returning ErrStateReused if the state was
+// consumed within the ttl.
+func (c *MemoryReplayCache) Consume(state string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	for s, expiry := range c.states {
+		if now.After(expiry) {
+			delete(c.states, s)
+		}
+	}
+	if _, ok := c.states[state]; ok {
+		return ErrStateReused
+	}
+	c.states[state] = now.Add(c.ttl)
+	return nil
+}
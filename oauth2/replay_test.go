@@ -0,0 +1,61 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestMemoryReplayCache(t *testing.T) {
+	cache := NewMemoryReplayCache(time.Minute)
+	assert.Nil(t, cache.Consume("state"))
+	assert.Equal(t, ErrStateReused, cache.Consume("state"))
+	assert.Nil(t, cache.Consume("other_state"))
+}
+
+func TestMemoryReplayCache_Expiry(t *testing.T) {
+	cache := NewMemoryReplayCache(time.Minute)
+	assert.Nil(t, cache.Consume("state"))
+	cache.states["state"] = time.Now().Add(-time.Second)
+	assert.Nil(t, cache.Consume("state"))
+}
+
+func TestReplayHandler(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"2YotnFZFEjr1zCsicMWpAA","token_type":"example"}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrStateReused, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// ReplayHandler chained to CallbackHandler, assert that:
+	// - the first callback with a state succeeds
+	// - a second callback with the same state calls the failure handler
+	// - error about the reused state is added to the ctx
+	handler := ReplayHandler(NewMemoryReplayCache(time.Minute), CallbackHandler(config, http.HandlerFunc(success), http.HandlerFunc(failure)))
+	ctx := WithState(context.Background(), "d4e5f6")
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
@@ -0,0 +1,95 @@
+package oauth2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/dghubble/gologin"
+	"golang.org/x/oauth2"
+)
+
+const (
+	accessTokenField = "access_token"
+	// maxTokenBodySize limits the size of JSON token request bodies.
+	maxTokenBodySize = 1 << 20
+)
+
+// Errors for missing or malformed access tokens.
+var (
+	ErrMissingAccessToken = fmt.Errorf("oauth2: missing token field %s", accessTokenField)
+	ErrInvalidTokenBody   = errors.New("oauth2: invalid JSON token request body")
+)
+
+// TokenHandler receives an OAuth2 access token obtained by a native (e.g.
+// mobile) client and adds it to the ctx. The token is read from a bearer
+// Authorization header, a JSON body with an "access_token" field, or an
+// "access_token" form field of a POST request. If a token is found, handling
+// delegates to the success handler, otherwise to the failure handler.
+//
+// Provider packages chain their own TokenHandler to check the token was
+// issued to the app and get the User. Provider APIs accept any valid token,
+// including tokens issued to other apps, so never log users in from a token
+// whose audience has not been checked.
+func TokenHandler(success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if req.Method != "POST" {
+			ctx = gologin.WithError(ctx, fmt.Errorf("Method not allowed"))
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		accessToken, err := parseAccessToken(req)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		token := &oauth2.Token{
+			AccessToken: accessToken,
+			TokenType:   "Bearer",
+		}
+		ctx = WithToken(ctx, token)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// parseAccessToken reads the access token from the Authorization header,
+// JSON body, or form of the request.
+func parseAccessToken(req *http.Request) (string, error) {
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		parts := strings.SplitN(auth, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") && strings.TrimSpace(parts[1]) != "" {
+			return strings.TrimSpace(parts[1]), nil
+		}
+		return "", ErrMissingAccessToken
+	}
+	mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		var body struct {
+			AccessToken string `json:"access_token"`
+		}
+		decoder := json.NewDecoder(io.LimitReader(req.Body, maxTokenBodySize))
+		if err := decoder.Decode(&body); err != nil {
+			return "", ErrInvalidTokenBody
+		}
+		if body.AccessToken == "" {
+			return "", ErrMissingAccessToken
+		}
+		return body.AccessToken, nil
+	}
+	req.ParseForm()
+	accessToken := req.PostForm.Get(accessTokenField)
+	if accessToken == "" {
+		return "", ErrMissingAccessToken
+	}
+	return accessToken, nil
+}
@@ -0,0 +1,90 @@
+package oauth2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenHandler(t *testing.T) {
+	form := url.Values{accessTokenField: {"form-token"}}
+	cases := []struct {
+		contentType   string
+		authorization string
+		body          string
+		expected      string
+	}{
+		{"application/x-www-form-urlencoded", "", form.Encode(), "form-token"},
+		{"application/json; charset=utf-8", "", `{"access_token": "json-token"}`, "json-token"},
+		{"", "Bearer header-token", "", "header-token"},
+		{"application/x-www-form-urlencoded", "bearer header-token", form.Encode(), "header-token"},
+	}
+	for _, c := range cases {
+		success := func(w http.ResponseWriter, req *http.Request) {
+			token, err := TokenFromContext(req.Context())
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, token.AccessToken)
+			assert.Equal(t, "Bearer", token.Type())
+			fmt.Fprintf(w, "success handler called")
+		}
+
+		// TokenHandler assert that:
+		// - the access token is read from the header, JSON body, or form
+		// - the Token is added to the success handler ctx
+		handler := TokenHandler(http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/", strings.NewReader(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestTokenHandler_Errors(t *testing.T) {
+	cases := []struct {
+		method        string
+		contentType   string
+		authorization string
+		body          string
+		expected      string
+	}{
+		{"GET", "", "Bearer header-token", "", "Method not allowed"},
+		{"POST", "application/x-www-form-urlencoded", "", "", ErrMissingAccessToken.Error()},
+		{"POST", "application/json", "", `{}`, ErrMissingAccessToken.Error()},
+		{"POST", "application/json", "", `{"access_token":`, ErrInvalidTokenBody.Error()},
+		{"POST", "", "Basic dXNlcjpwYXNz", "", ErrMissingAccessToken.Error()},
+		{"POST", "", "Bearer ", "", ErrMissingAccessToken.Error()},
+	}
+	for _, c := range cases {
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			if assert.NotNil(t, err) {
+				assert.Equal(t, c.expected, err.Error())
+			}
+			fmt.Fprintf(w, "failure handler called")
+		}
+		handler := TokenHandler(testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(c.method, "/", strings.NewReader(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
@@ -0,0 +1,339 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	"golang.org/x/oauth2"
+)
+
+// ErrTokenNotFound is returned when a TokenStore has no Token for a user.
+var ErrTokenNotFound = errors.New("oauth2: Token not found")
+
+// TokenStore persists OAuth2 Tokens, keyed by the provider name and the
+// provider's subject identifier for the user (see gologin.Identity).
+type TokenStore interface {
+	// Get returns the Token of the user or ErrTokenNotFound.
+	Get(ctx context.Context, provider, subject string) (*oauth2.Token, error)
+	// Put stores the Token of the user, replacing any existing Token.
+	Put(ctx context.Context, provider, subject string, token *oauth2.Token) error
+	// Delete removes the Token of the user, if any.
+	Delete(ctx context.Context, provider, subject string) error
+}
+
+// StoreTokenHandler puts the Token from the ctx into the TokenStore, keyed by
+// the gologin.Identity from the ctx, and calls the success handler. Chain it
+// after a provider CallbackHandler. On errors, handling delegates to the
+// failure handler.
+func StoreTokenHandler(store TokenStore, success, failure http.Handler) http.Handler {
+	bind := func(w http.ResponseWriter, req *http.Request) TokenStore {
+		return store
+	}
+	return storeTokenHandler(bind, success, failure)
+}
+
+// storeTokenHandler puts the Token from the ctx into the TokenStore returned
+// by bind for the request.
+func storeTokenHandler(bind func(w http.ResponseWriter, req *http.Request) TokenStore, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		identity, err := gologin.IdentityFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if err := bind(w, req).Put(ctx, identity.Provider, identity.Subject, token); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// StoredTokenSource returns a TokenSource for the user's stored Token. When
+// the Token expires, it is refreshed using the config and the refreshed Token
+// is written back to the TokenStore. The ctx is used for refresh requests.
+func StoredTokenSource(ctx context.Context, config *oauth2.Config, store TokenStore, provider, subject string) (oauth2.TokenSource, error) {
+	token, err := store.Get(ctx, provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	return &storedTokenSource{
+		ctx:      ctx,
+		src:      config.TokenSource(ctx, token),
+		store:    store,
+		provider: provider,
+		subject:  subject,
+		last:     token,
+	}, nil
+}
+
+// storedTokenSource writes Tokens which differ from the last seen Token back
+// to the TokenStore.
+type storedTokenSource struct {
+	ctx      context.Context
+	src      oauth2.TokenSource
+	store    TokenStore
+	provider string
+	subject  string
+
+	mu   sync.Mutex
+	last *oauth2.Token
+}
+
+func (s *storedTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	if token.AccessToken != s.last.AccessToken {
+		if err := s.store.Put(s.ctx, s.provider, s.subject, token); err != nil {
+			return nil, err
+		}
+		s.last = token
+	}
+	return token, nil
+}
+
+// MemoryTokenStore is an in-memory TokenStore. Tokens are lost on restart and
+// are not shared between replicas.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]*oauth2.Token
+}
+
+// NewMemoryTokenStore returns a new MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		tokens: make(map[string]*oauth2.Token),
+	}
+}
+
+// Get returns the Token of the user or ErrTokenNotFound.
+func (s *MemoryTokenStore) Get(ctx context.Context, provider, subject string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.tokens[storeKey(provider, subject)]
+	if !ok {
+		return nil, ErrTokenNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+// Put stores the Token of the user.
+func (s *MemoryTokenStore) Put(ctx context.Context, provider, subject string, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *token
+	s.tokens[storeKey(provider, subject)] = &copied
+	return nil
+}
+
+// Delete removes the Token of the user.
+func (s *MemoryTokenStore) Delete(ctx context.Context, provider, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, storeKey(provider, subject))
+	return nil
+}
+
+// FileTokenStore is a TokenStore which writes each Token as a JSON file in a
+// directory. Files are readable only by the owner since Tokens are secrets.
+type FileTokenStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileTokenStore returns a new FileTokenStore which stores Tokens in the
+// directory, creating it if needed.
+func NewFileTokenStore(dir string) (*FileTokenStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileTokenStore{dir: dir}, nil
+}
+
+// Get returns the Token of the user or ErrTokenNotFound.
+func (s *FileTokenStore) Get(ctx context.Context, provider, subject string) (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := ioutil.ReadFile(s.path(provider, subject))
+	if os.IsNotExist(err) {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	token := new(oauth2.Token)
+	if err := json.Unmarshal(data, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// Put writes the Token of the user, replacing the file atomically.
+func (s *FileTokenStore) Put(ctx context.Context, provider, subject string, token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := ioutil.TempFile(s.dir, ".token-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(provider, subject))
+}
+
+// Delete removes the Token file of the user.
+func (s *FileTokenStore) Delete(ctx context.Context, provider, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := os.Remove(s.path(provider, subject))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path returns the file path of a user's Token. Names are base64 encoded so
+// subjects cannot escape the directory.
+func (s *FileTokenStore) path(provider, subject string) string {
+	name := base64.RawURLEncoding.EncodeToString([]byte(provider)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(subject)) + ".json"
+	return filepath.Join(s.dir, name)
+}
+
+// CookieTokenStore stores Tokens in encrypted cookies, one per provider, so no
+// server-side storage is needed. Since cookies are only available during a
+// request, Bind the store to a request to get a TokenStore.
+type CookieTokenStore struct {
+	config gologin.CookieConfig
+	key    []byte
+}
+
+// NewCookieTokenStore returns a new CookieTokenStore which encrypts Tokens
+// with AES-GCM under the key (16, 24, or 32 bytes) and issues cookies named
+// after the CookieConfig Name with a "-{provider}" suffix, where the provider
+// is base64url encoded.
+func NewCookieTokenStore(config gologin.CookieConfig, key []byte) *CookieTokenStore {
+	return &CookieTokenStore{config: config, key: key}
+}
+
+// Bind returns a TokenStore which reads cookies from the request and writes
+// cookies to the response.
+func (s *CookieTokenStore) Bind(w http.ResponseWriter, req *http.Request) TokenStore {
+	return &cookieTokenStore{CookieTokenStore: s, w: w, req: req}
+}
+
+// StoreTokenHandler puts the Token from the ctx into a cookie, like the
+// package StoreTokenHandler.
+func (s *CookieTokenStore) StoreTokenHandler(success, failure http.Handler) http.Handler {
+	return storeTokenHandler(s.Bind, success, failure)
+}
+
+// cookieTokenStore is a CookieTokenStore bound to a request.
+type cookieTokenStore struct {
+	*CookieTokenStore
+	w   http.ResponseWriter
+	req *http.Request
+}
+
+// storedToken records the subject alongside the Token so a cookie cannot be
+// read as another user's Token.
+type storedToken struct {
+	Subject string        `json:"sub"`
+	Token   *oauth2.Token `json:"token"`
+}
+
+func (s *cookieTokenStore) Get(ctx context.Context, provider, subject string) (*oauth2.Token, error) {
+	config := s.cookieConfig(provider)
+	value, err := internal.CookieValue(s.req, config)
+	if err == http.ErrNoCookie {
+		return nil, ErrTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, internal.ErrInvalidSealed
+	}
+	data, err := internal.Open(s.key, sealed, []byte(config.Name))
+	if err != nil {
+		return nil, err
+	}
+	stored := new(storedToken)
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, err
+	}
+	if stored.Subject != subject || stored.Token == nil {
+		return nil, ErrTokenNotFound
+	}
+	return stored.Token, nil
+}
+
+func (s *cookieTokenStore) Put(ctx context.Context, provider, subject string, token *oauth2.Token) error {
+	config := s.cookieConfig(provider)
+	data, err := json.Marshal(storedToken{Subject: subject, Token: token})
+	if err != nil {
+		return err
+	}
+	sealed, err := internal.Seal(s.key, data, []byte(config.Name))
+	if err != nil {
+		return err
+	}
+	http.SetCookie(s.w, internal.NewCookie(config, base64.RawURLEncoding.EncodeToString(sealed)))
+	return nil
+}
+
+func (s *cookieTokenStore) Delete(ctx context.Context, provider, subject string) error {
+	http.SetCookie(s.w, internal.ExpiredCookie(s.cookieConfig(provider)))
+	return nil
+}
+
+// cookieConfig returns a copy of the CookieConfig which names the provider's
+// Token cookie. Providers are base64 encoded since they may be issuer URLs,
+// which are not valid cookie names.
+func (s *CookieTokenStore) cookieConfig(provider string) gologin.CookieConfig {
+	config := s.config
+	config.Name = config.Name + "-" + base64.RawURLEncoding.EncodeToString([]byte(provider))
+	return config
+}
+
+func storeKey(provider, subject string) string {
+	return provider + "\x00" + subject
+}
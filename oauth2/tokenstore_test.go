@@ -0,0 +1,182 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+var testTokenKey = []byte("0123456789abcdef")
+
+// assertTokenStore checks the Get, Put, and Delete behavior of a TokenStore.
+func assertTokenStore(t *testing.T, store TokenStore) {
+	ctx := context.Background()
+	_, err := store.Get(ctx, "github", "917408")
+	assert.Equal(t, ErrTokenNotFound, err)
+
+	token := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", TokenType: "bearer"}
+	assert.Nil(t, store.Put(ctx, "github", "917408", token))
+	stored, err := store.Get(ctx, "github", "917408")
+	assert.Nil(t, err)
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, "access", stored.AccessToken)
+		assert.Equal(t, "refresh", stored.RefreshToken)
+	}
+	// Tokens are keyed by provider and subject
+	_, err = store.Get(ctx, "github", "other")
+	assert.Equal(t, ErrTokenNotFound, err)
+	_, err = store.Get(ctx, "google", "917408")
+	assert.Equal(t, ErrTokenNotFound, err)
+
+	assert.Nil(t, store.Delete(ctx, "github", "917408"))
+	_, err = store.Get(ctx, "github", "917408")
+	assert.Equal(t, ErrTokenNotFound, err)
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	assertTokenStore(t, NewMemoryTokenStore())
+}
+
+func TestFileTokenStore(t *testing.T) {
+	store, err := NewFileTokenStore(t.TempDir())
+	assert.Nil(t, err)
+	assertTokenStore(t, store)
+	// subjects cannot escape the directory
+	assert.Nil(t, store.Put(context.Background(), "github", "../../etc/passwd", &oauth2.Token{}))
+}
+
+func TestCookieTokenStore(t *testing.T) {
+	store := NewCookieTokenStore(gologin.DebugOnlyCookieConfig, testTokenKey)
+	ctx := context.Background()
+	token := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh"}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, store.Bind(w, req).Put(ctx, "github", "917408", token))
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, "gologin-temporary-cookie-Z2l0aHVi", cookies[0].Name)
+	assert.NotContains(t, cookies[0].Value, "access")
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	bound := store.Bind(httptest.NewRecorder(), req)
+	stored, err := bound.Get(ctx, "github", "917408")
+	assert.Nil(t, err)
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, "access", stored.AccessToken)
+	}
+	// the cookie cannot be read as another user's Token
+	_, err = bound.Get(ctx, "github", "other")
+	assert.Equal(t, ErrTokenNotFound, err)
+	_, err = bound.Get(ctx, "google", "917408")
+	assert.Equal(t, ErrTokenNotFound, err)
+	// the cookie cannot be read with another key
+	other := NewCookieTokenStore(gologin.DebugOnlyCookieConfig, []byte("fedcba9876543210"))
+	_, err = other.Bind(httptest.NewRecorder(), req).Get(ctx, "github", "917408")
+	assert.NotNil(t, err)
+}
+
+func TestCookieTokenStore_IssuerProvider(t *testing.T) {
+	store := NewCookieTokenStore(gologin.DebugOnlyCookieConfig, testTokenKey)
+	ctx := context.Background()
+	// oidc Identities use the issuer URL as the provider
+	provider := "https://accounts.example.com/"
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	assert.Nil(t, store.Bind(w, req).Put(ctx, provider, "917408", &oauth2.Token{AccessToken: "access"}))
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.NotContains(t, cookies[0].Name, ":")
+	assert.NotContains(t, cookies[0].Name, "/")
+
+	req, _ = http.NewRequest("GET", "/", nil)
+	req.AddCookie(cookies[0])
+	stored, err := store.Bind(httptest.NewRecorder(), req).Get(ctx, provider, "917408")
+	assert.Nil(t, err)
+	if assert.NotNil(t, stored) {
+		assert.Equal(t, "access", stored.AccessToken)
+	}
+}
+
+func TestStoreTokenHandler(t *testing.T) {
+	store := NewMemoryTokenStore()
+	token := &oauth2.Token{AccessToken: "access"}
+	ctx := WithToken(context.Background(), token)
+	ctx = gologin.WithIdentity(ctx, &gologin.Identity{Provider: "github", Subject: "917408"})
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// StoreTokenHandler assert that:
+	// - the ctx Token is stored for the ctx Identity
+	// - success handler is called
+	handler := StoreTokenHandler(store, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+	stored, err := store.Get(context.Background(), "github", "917408")
+	assert.Nil(t, err)
+	assert.Equal(t, token, stored)
+}
+
+func TestStoreTokenHandler_MissingIdentity(t *testing.T) {
+	ctx := WithToken(context.Background(), &oauth2.Token{AccessToken: "access"})
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "Context missing Identity", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+	handler := StoreTokenHandler(NewMemoryTokenStore(), testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestStoredTokenSource(t *testing.T) {
+	server := NewAccessTokenServer(t, `{"access_token":"refreshed","refresh_token":"refresh2","token_type":"bearer","expires_in":3600}`)
+	defer server.Close()
+	config := &oauth2.Config{
+		Endpoint: oauth2.Endpoint{
+			TokenURL: server.URL,
+		},
+	}
+	store := NewMemoryTokenStore()
+	ctx := context.Background()
+	expired := &oauth2.Token{AccessToken: "access", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)}
+	assert.Nil(t, store.Put(ctx, "github", "917408", expired))
+
+	// StoredTokenSource assert that:
+	// - the expired Token is refreshed
+	// - the refreshed Token is written back to the store
+	src, err := StoredTokenSource(ctx, config, store, "github", "917408")
+	assert.Nil(t, err)
+	token, err := src.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "refreshed", token.AccessToken)
+	stored, err := store.Get(ctx, "github", "917408")
+	assert.Nil(t, err)
+	assert.Equal(t, "refreshed", stored.AccessToken)
+	assert.Equal(t, "refresh2", stored.RefreshToken)
+
+	// missing Tokens are reported
+	_, err = StoredTokenSource(ctx, config, store, "github", "other")
+	assert.Equal(t, ErrTokenNotFound, err)
+}
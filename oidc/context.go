@@ -0,0 +1,59 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+
+	goidc "github.com/coreos/go-oidc"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	nonceKey key = iota
+	idTokenKey
+	userKey
+)
+
+// WithNonce returns a copy of ctx that stores the nonce value.
+func WithNonce(ctx context.Context, nonce string) context.Context {
+	return context.WithValue(ctx, nonceKey, nonce)
+}
+
+// NonceFromContext returns the nonce value from the ctx.
+func NonceFromContext(ctx context.Context) (string, error) {
+	nonce, ok := ctx.Value(nonceKey).(string)
+	if !ok {
+		return "", fmt.Errorf("oidc: Context missing nonce value")
+	}
+	return nonce, nil
+}
+
+// WithIDToken returns a copy of ctx that stores the verified ID Token.
+func WithIDToken(ctx context.Context, idToken *goidc.IDToken) context.Context {
+	return context.WithValue(ctx, idTokenKey, idToken)
+}
+
+// IDTokenFromContext returns the verified ID Token from the ctx.
+func IDTokenFromContext(ctx context.Context) (*goidc.IDToken, error) {
+	idToken, ok := ctx.Value(idTokenKey).(*goidc.IDToken)
+	if !ok {
+		return nil, fmt.Errorf("oidc: Context missing ID Token")
+	}
+	return idToken, nil
+}
+
+// WithUser returns a copy of ctx that stores the OpenID Connect User.
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns the OpenID Connect User from the ctx.
+func UserFromContext(ctx context.Context) (*User, error) {
+	user, ok := ctx.Value(userKey).(*User)
+	if !ok {
+		return nil, fmt.Errorf("oidc: Context missing OpenID Connect User")
+	}
+	return user, nil
+}
@@ -0,0 +1,57 @@
+package oidc
+
+import (
+	"context"
+	"testing"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextNonce(t *testing.T) {
+	expectedNonce := "nonce"
+	ctx := WithNonce(context.Background(), expectedNonce)
+	nonce, err := NonceFromContext(ctx)
+	assert.Equal(t, expectedNonce, nonce)
+	assert.Nil(t, err)
+}
+
+func TestContextNonce_Error(t *testing.T) {
+	nonce, err := NonceFromContext(context.Background())
+	assert.Equal(t, "", nonce)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oidc: Context missing nonce value", err.Error())
+	}
+}
+
+func TestContextIDToken(t *testing.T) {
+	expectedIDToken := &goidc.IDToken{Subject: "12"}
+	ctx := WithIDToken(context.Background(), expectedIDToken)
+	idToken, err := IDTokenFromContext(ctx)
+	assert.Equal(t, expectedIDToken, idToken)
+	assert.Nil(t, err)
+}
+
+func TestContextIDToken_Error(t *testing.T) {
+	idToken, err := IDTokenFromContext(context.Background())
+	assert.Nil(t, idToken)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oidc: Context missing ID Token", err.Error())
+	}
+}
+
+func TestContextUser(t *testing.T) {
+	expectedUser := &User{Subject: "12", Name: "Gopher"}
+	ctx := WithUser(context.Background(), expectedUser)
+	user, err := UserFromContext(ctx)
+	assert.Equal(t, expectedUser, user)
+	assert.Nil(t, err)
+}
+
+func TestContextUser_Error(t *testing.T) {
+	user, err := UserFromContext(context.Background())
+	assert.Nil(t, user)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "oidc: Context missing OpenID Connect User", err.Error())
+	}
+}
@@ -0,0 +1,3 @@
+// Package oidc provides OpenID Connect login and callback handlers which
+// discover provider endpoints, bind ID Tokens to a nonce, and verify them.
+package oidc
@@ -0,0 +1,235 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// OpenID Connect login errors
+var (
+	ErrMissingIDToken = errors.New("oidc: Token response missing id_token")
+	ErrInvalidNonce   = errors.New("oidc: Invalid ID Token nonce")
+	// ErrMissingVerifier is returned by handlers given a nil Verifier, so ID
+	// Tokens are never trusted without verification.
+	ErrMissingVerifier = errors.New("oidc: Missing ID Token verifier")
+)
+
+// NewProvider uses OpenID Connect discovery to construct a Provider for the
+// given issuer URL (e.g. "https://accounts.example.com/realms/main"). The
+// ctx is retained by the Provider to fetch signing keys.
+func NewProvider(ctx context.Context, issuer string) (*goidc.Provider, error) {
+	return goidc.NewProvider(ctx, issuer)
+}
+
+// Verifier verifies raw ID Tokens. A *goidc.IDTokenVerifier is a Verifier.
+type Verifier interface {
+	Verify(ctx context.Context, rawIDToken string) (*goidc.IDToken, error)
+}
+
+// NewVerifier uses OpenID Connect discovery to return a Verifier of ID Tokens
+// issued by the issuer URL to the client ID. The ctx is retained to fetch
+// signing keys.
+func NewVerifier(ctx context.Context, issuer, clientID string) (Verifier, error) {
+	provider, err := goidc.NewProvider(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Verifier(&goidc.Config{ClientID: clientID}), nil
+}
+
+// CSRFHandler checks for state and nonce cookies. If found, the values are
+// read and added to the ctx. Otherwise, non-guessable values are added to the
+// ctx and to (short-lived) cookies issued to the requester.
+//
+// The nonce cookie is named after the state cookie with a "-nonce" suffix.
+// Both values are required by LoginHandler and CallbackHandler.
+func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	success = NonceHandler(nonceCookieConfig(config), success)
+	return oauth2Login.CSRFHandler(config, success)
+}
+
+// NonceHandler checks for a nonce cookie. If found, the nonce value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) nonce cookie issued to the requester.
+//
+// The nonce binds the ID Token issued by the provider to the browser which
+// started the login. If you wish to issue nonces differently, write a
+// http.Handler which sets the ctx nonce using WithNonce(ctx, nonce).
+func NonceHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		val, err := internal.CookieValue(req, config)
+		if err == nil {
+			// add the cookie nonce to the ctx
+			ctx = WithNonce(ctx, val)
+		} else {
+			if err != http.ErrNoCookie {
+				ctx = internal.WithCookieError(ctx, err)
+			}
+			// add Cookie with a random nonce
+			val := internal.RandomValue()
+			http.SetCookie(w, internal.NewCookie(config, val))
+			ctx = WithNonce(ctx, val)
+		}
+		ctx = internal.WithTempCookie(ctx, config)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// LoginHandler handles OpenID Connect login requests by reading the state
+// and nonce values from the ctx and redirecting requests to the AuthURL with
+// those values.
+func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		nonce, err := NonceFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		authOpts := append([]oauth2.AuthCodeOption{goidc.Nonce(nonce)}, opts...)
+		oauth2Login.LoginHandler(config, failure, authOpts...).ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
+
+// CallbackHandler handles OpenID Connect redirection URI requests and adds
+// the access token, verified ID Token, and User to the ctx. The Identity
+// Provider is the given name (e.g. "keycloak"), like the name a Provider is
+// mounted under. If authentication succeeds, handling delegates to the
+// success handler, otherwise to the failure handler.
+func CallbackHandler(name string, config *oauth2.Config, provider *goidc.Provider, success, failure http.Handler) http.Handler {
+	success = oidcHandler(name, config, provider, success, failure)
+	success = IDTokenHandler(provider.Verifier(&goidc.Config{ClientID: config.ClientID}), success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// IDTokenHandler is a http.Handler that verifies the ID Token of the OAuth2
+// Token in the ctx and checks that it was issued for the ctx nonce. If
+// successful, the ID Token is added to the ctx and the success handler is
+// called. Otherwise, the failure handler is called.
+//
+// Providers which read their User from the ID Token chain it after an oauth2
+// CallbackHandler, with the nonce issued by CSRFHandler and sent by
+// LoginHandler. If the verifier is nil, the failure handler is called with
+// ErrMissingVerifier.
+func IDTokenHandler(verifier Verifier, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		if verifier == nil {
+			ctx = gologin.WithError(ctx, ErrMissingVerifier)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		nonce, err := NonceFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		idToken, err := verifyIDToken(ctx, verifier, token, nonce)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithIDToken(ctx, idToken)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// oidcHandler is a http.Handler that gets the OAuth2 Token and verified ID
+// Token from the ctx and merges in the provider's userinfo claims. If
+// successful, the User and its Identity of the named provider are added to
+// the ctx and the success handler is called. Otherwise, the failure handler
+// is called.
+func oidcHandler(name string, config *oauth2.Config, provider *goidc.Provider, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		idToken, err := IDTokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user, err := newClient(provider, config.TokenSource(ctx, token)).User(ctx, idToken)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(name, user))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// verifyIDToken verifies the signature and standard claims of the Token's
+// id_token and checks that it was issued for the given nonce.
+func verifyIDToken(ctx context.Context, verifier Verifier, token *oauth2.Token, nonce string) (*goidc.IDToken, error) {
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if nonce == "" || idToken.Nonce != nonce {
+		return nil, ErrInvalidNonce
+	}
+	return idToken, nil
+}
+
+// nonceCookieConfig returns a copy of the state CookieConfig which names the
+// nonce cookie.
+func nonceCookieConfig(config gologin.CookieConfig) gologin.CookieConfig {
+	config.Name = config.Name + "-nonce"
+	return config
+}
+
+// newIdentity returns the Identity of the OpenID Connect User of the named
+// provider.
+func newIdentity(name string, user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:      name,
+		Subject:       user.Subject,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		Username:      user.PreferredUsername,
+		AvatarURL:     user.Picture,
+	}
+}
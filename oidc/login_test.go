@@ -0,0 +1,333 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestNonceHandler(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	success := func(w http.ResponseWriter, req *http.Request) {
+		nonce, err := NonceFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.NotEqual(t, "", nonce)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// NonceHandler without a nonce cookie, assert that:
+	// - a nonce cookie is issued
+	// - the nonce is added to the ctx of the success handler
+	handler := NonceHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, config.Name, cookies[0].Name)
+	}
+}
+
+func TestNonceHandler_ExistingCookie(t *testing.T) {
+	config := gologin.DebugOnlyCookieConfig
+	success := func(w http.ResponseWriter, req *http.Request) {
+		nonce, err := NonceFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "cookie_nonce", nonce)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// NonceHandler with a nonce cookie, assert that:
+	// - the cookie nonce is added to the ctx of the success handler
+	// - no new cookie is issued
+	handler := NonceHandler(config, http.HandlerFunc(success))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.AddCookie(&http.Cookie{Name: config.Name, Value: "cookie_nonce"})
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 0)
+}
+
+func TestLoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID:    testClientID,
+		RedirectURL: "redirect_url",
+		Endpoint: oauth2.Endpoint{
+			AuthURL: "https://accounts.example.com/authorize",
+		},
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// LoginHandler assert that:
+	// - redirects to the AuthURL with the ctx state and nonce
+	loginHandler := LoginHandler(config, failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := oauth2Login.WithState(context.Background(), "state_val")
+	ctx = WithNonce(ctx, "nonce_val")
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "state_val", location.Query().Get("state"))
+		assert.Equal(t, "nonce_val", location.Query().Get("nonce"))
+	}
+}
+
+func TestLoginHandler_MissingCtxNonce(t *testing.T) {
+	config := &oauth2.Config{}
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oidc: Context missing nonce value", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// LoginHandler cannot get the nonce from the ctx, assert that:
+	// - failure handler is called
+	// - error about missing nonce is added to the ctx
+	loginHandler := LoginHandler(config, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	ctx := oauth2Login.WithState(context.Background(), "state_val")
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestOIDCHandler(t *testing.T) {
+	p := newOIDCTestServer(t, `{"sub": "248289761001", "name": "Ivy Crimson", "email": "ivy@example.com", "email_verified": true}`)
+	defer p.Close()
+	provider, err := NewProvider(context.Background(), p.server.URL)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := p.IDToken(t, "248289761001", "nonce_val", map[string]interface{}{"name": "Ivy"})
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = WithNonce(ctx, "nonce_val")
+
+	config := &oauth2.Config{ClientID: testClientID}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		idToken, err := IDTokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "248289761001", idToken.Subject)
+		user, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "248289761001", user.Subject)
+		// ID Token claims take precedence over userinfo claims
+		assert.Equal(t, "Ivy", user.Name)
+		assert.Equal(t, "ivy@example.com", user.Email)
+		assert.True(t, user.EmailVerified)
+		assert.Equal(t, "ivy@example.com", user.Claims["email"])
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "keycloak", identity.Provider)
+		assert.Equal(t, "248289761001", identity.Subject)
+		assert.True(t, identity.EmailVerified)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// IDTokenHandler and oidcHandler, assert that:
+	// - ID Token is verified and its nonce matches the ctx nonce
+	// - userinfo claims are merged into the User
+	// - success handler is called with the ID Token and User in the ctx
+	verifier := provider.Verifier(&goidc.Config{ClientID: testClientID})
+	handler := IDTokenHandler(verifier, oidcHandler("keycloak", config, provider, http.HandlerFunc(success), failure), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestNewVerifier(t *testing.T) {
+	p := newOIDCTestServer(t, `{}`)
+	defer p.Close()
+	verifier, err := NewVerifier(context.Background(), p.server.URL, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	idToken, err := verifier.Verify(context.Background(), p.IDToken(t, "248289761001", "nonce_val", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, "248289761001", idToken.Subject)
+	// ID Token issued to another client
+	_, err = verifier.Verify(context.Background(), p.IDToken(t, "248289761001", "nonce_val", map[string]interface{}{"aud": "other-client"}))
+	assert.NotNil(t, err)
+}
+
+func TestIDTokenHandler(t *testing.T) {
+	p := newOIDCTestServer(t, `{}`)
+	defer p.Close()
+	verifier, err := NewVerifier(context.Background(), p.server.URL, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := p.IDToken(t, "248289761001", "nonce_val", nil)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = WithNonce(ctx, "nonce_val")
+
+	success := func(w http.ResponseWriter, req *http.Request) {
+		idToken, err := IDTokenFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, "248289761001", idToken.Subject)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// IDTokenHandler assert that:
+	// - success handler is called with the verified ID Token in the ctx
+	handler := IDTokenHandler(verifier, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestIDTokenHandler_InvalidNonce(t *testing.T) {
+	p := newOIDCTestServer(t, `{}`)
+	defer p.Close()
+	verifier, err := NewVerifier(context.Background(), p.server.URL, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := p.IDToken(t, "248289761001", "other_nonce", nil)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = WithNonce(ctx, "nonce_val")
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrInvalidNonce, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// IDTokenHandler with an ID Token issued for another nonce, assert that:
+	// - failure handler is called
+	// - error about the invalid nonce is added to the ctx
+	handler := IDTokenHandler(verifier, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestIDTokenHandler_MissingIDToken(t *testing.T) {
+	p := newOIDCTestServer(t, `{}`)
+	defer p.Close()
+	verifier, err := NewVerifier(context.Background(), p.server.URL, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx := oauth2Login.WithToken(context.Background(), &oauth2.Token{AccessToken: "any-token"})
+	ctx = WithNonce(ctx, "nonce_val")
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrMissingIDToken, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// IDTokenHandler with a Token lacking an id_token, assert that:
+	// - failure handler is called
+	// - error about the missing ID Token is added to the ctx
+	handler := IDTokenHandler(verifier, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestIDTokenHandler_MissingCtxNonce(t *testing.T) {
+	p := newOIDCTestServer(t, `{}`)
+	defer p.Close()
+	verifier, err := NewVerifier(context.Background(), p.server.URL, testClientID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := p.IDToken(t, "248289761001", "nonce_val", nil)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, fmt.Errorf("oidc: Context missing nonce value"), err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// IDTokenHandler without a ctx nonce, assert that:
+	// - failure handler is called
+	handler := IDTokenHandler(verifier, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestOIDCHandler_UserInfoSubjectMismatch(t *testing.T) {
+	p := newOIDCTestServer(t, `{"sub": "someone-else"}`)
+	defer p.Close()
+	provider, err := NewProvider(context.Background(), p.server.URL)
+	if !assert.Nil(t, err) {
+		return
+	}
+	rawIDToken := p.IDToken(t, "248289761001", "nonce_val", nil)
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": rawIDToken})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = WithNonce(ctx, "nonce_val")
+
+	config := &oauth2.Config{ClientID: testClientID}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUserInfoSubjectMismatch, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// oidcHandler with userinfo for another subject, assert that:
+	// - failure handler is called
+	verifier := provider.Verifier(&goidc.Config{ClientID: testClientID})
+	handler := IDTokenHandler(verifier, oidcHandler("keycloak", config, provider, success, http.HandlerFunc(failure)), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestIDTokenHandler_MissingVerifier(t *testing.T) {
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{"id_token": "any-id-token"})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = WithNonce(ctx, "nonce_val")
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrMissingVerifier, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// IDTokenHandler with a nil verifier, assert that:
+	// - failure handler is called, rather than trusting the ID Token
+	handler := IDTokenHandler(nil, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
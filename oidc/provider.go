@@ -0,0 +1,34 @@
+package oidc
+
+import (
+	"net/http"
+
+	goidc "github.com/coreos/go-oidc"
+	"github.com/dghubble/gologin"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider with the given name (e.g. "keycloak")
+// which performs OpenID Connect web logins with the given config and
+// discovered provider, for mounting with a gologin.Registry.
+func Provider(name string, config *oauth2.Config, provider *goidc.Provider) gologin.Provider {
+	return &oidcProvider{name: name, config: config, provider: provider}
+}
+
+type oidcProvider struct {
+	name     string
+	config   *oauth2.Config
+	provider *goidc.Provider
+}
+
+func (p *oidcProvider) Name() string {
+	return p.name
+}
+
+func (p *oidcProvider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *oidcProvider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, CallbackHandler(p.name, p.config, p.provider, success, failure))
+}
@@ -0,0 +1,62 @@
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dghubble/gologin/internal/oidctest"
+)
+
+const testClientID = "client_id"
+
+// testProvider is a mock OpenID Connect provider which serves discovery,
+// JSON Web Key Set, and userinfo endpoints and signs ID Tokens.
+type testProvider struct {
+	server *httptest.Server
+	signer *oidctest.Signer
+}
+
+// newOIDCTestServer returns a new testProvider whose userinfo endpoint
+// responds with the given json data. The caller must close the server.
+func newOIDCTestServer(t *testing.T, userInfoJSON string) *testProvider {
+	p := &testProvider{signer: oidctest.NewSigner(t)}
+	mux := http.NewServeMux()
+	p.server = httptest.NewServer(mux)
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                p.server.URL,
+			"authorization_endpoint":                p.server.URL + "/authorize",
+			"token_endpoint":                        p.server.URL + "/token",
+			"jwks_uri":                              p.server.URL + "/keys",
+			"userinfo_endpoint":                     p.server.URL + "/userinfo",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.Handle("/keys", p.signer.KeysHandler())
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, userInfoJSON)
+	})
+	return p
+}
+
+// IDToken returns a signed ID Token for the subject and nonce with any extra
+// claims.
+func (p *testProvider) IDToken(t *testing.T, subject, nonce string, extra map[string]interface{}) string {
+	claims := map[string]interface{}{
+		"iss":   p.server.URL,
+		"aud":   testClientID,
+		"sub":   subject,
+		"nonce": nonce,
+	}
+	return p.signer.IDToken(t, claims, extra)
+}
+
+// Close closes the underlying server.
+func (p *testProvider) Close() {
+	p.server.Close()
+}
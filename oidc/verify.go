@@ -0,0 +1,99 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	goidc "github.com/coreos/go-oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrUserInfoSubjectMismatch is returned when the userinfo endpoint describes
+// a different subject than the verified ID Token.
+var ErrUserInfoSubjectMismatch = errors.New("oidc: userinfo subject does not match ID Token subject")
+
+// User is an OpenID Connect end-user, built from the verified ID Token claims
+// merged with any claims returned by the provider's userinfo endpoint.
+//
+// ref: https://openid.net/specs/openid-connect-core-1_0.html#StandardClaims
+type User struct {
+	Subject           string `json:"sub"`
+	Name              string `json:"name"`
+	GivenName         string `json:"given_name"`
+	FamilyName        string `json:"family_name"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Picture           string `json:"picture"`
+	Locale            string `json:"locale"`
+	// Claims holds all merged claims, including provider-specific ones.
+	Claims map[string]interface{} `json:"-"`
+}
+
+// client is an OpenID Connect client for obtaining the current User.
+type client struct {
+	provider    *goidc.Provider
+	tokenSource oauth2.TokenSource
+}
+
+func newClient(provider *goidc.Provider, tokenSource oauth2.TokenSource) *client {
+	return &client{
+		provider:    provider,
+		tokenSource: tokenSource,
+	}
+}
+
+// User returns the User described by the ID Token claims and, if the
+// provider advertises a userinfo endpoint, the userinfo claims. ID Token
+// claims take precedence since they are signed by the provider.
+func (c *client) User(ctx context.Context, idToken *goidc.IDToken) (*User, error) {
+	claims := make(map[string]interface{})
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	if c.hasUserInfo() {
+		info, err := c.provider.UserInfo(ctx, c.tokenSource)
+		if err != nil {
+			return nil, err
+		}
+		if info.Subject != idToken.Subject {
+			return nil, ErrUserInfoSubjectMismatch
+		}
+		infoClaims := make(map[string]interface{})
+		if err := info.Claims(&infoClaims); err != nil {
+			return nil, err
+		}
+		for name, value := range infoClaims {
+			if _, ok := claims[name]; !ok {
+				claims[name] = value
+			}
+		}
+	}
+	return newUser(claims)
+}
+
+// hasUserInfo returns true if the provider metadata advertises a userinfo
+// endpoint.
+func (c *client) hasUserInfo() bool {
+	var metadata struct {
+		UserInfoURL string `json:"userinfo_endpoint"`
+	}
+	if err := c.provider.Claims(&metadata); err != nil {
+		return false
+	}
+	return metadata.UserInfoURL != ""
+}
+
+// newUser decodes the standard claims into a User which retains all claims.
+func newUser(claims map[string]interface{}) (*User, error) {
+	data, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	user := &User{Claims: claims}
+	if err := json.Unmarshal(data, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,59 @@
+package gologin
+
+import (
+	"net/http"
+)
+
+// Provider is an identity provider whose web login flow can be mounted by a
+// Registry. Provider packages (e.g. github, google, twitter) implement it
+// with their own CSRF, login, and callback handlers.
+type Provider interface {
+	// Name is the URL-safe name of the provider (e.g. "github"), used as its
+	// route prefix.
+	Name() string
+	// LoginHandler returns the http.Handler which starts a login, issuing
+	// any temporary cookies with the CookieConfig.
+	LoginHandler(config CookieConfig, failure http.Handler) http.Handler
+	// CallbackHandler returns the http.Handler which completes a login,
+	// reading any temporary cookies with the CookieConfig.
+	CallbackHandler(config CookieConfig, success, failure http.Handler) http.Handler
+}
+
+// Registry is a set of Providers which can be mounted together.
+type Registry struct {
+	providers []Provider
+}
+
+// NewRegistry returns a new Registry of the given Providers.
+func NewRegistry(providers ...Provider) *Registry {
+	return &Registry{
+		providers: providers,
+	}
+}
+
+// Providers returns the Providers in the Registry.
+func (r *Registry) Providers() []Provider {
+	return r.providers
+}
+
+// Mount registers "/{name}/login" and "/{name}/callback" routes on the mux
+// for each Provider. All Providers share the CookieConfig, success handler,
+// and failure handler. Configure each Provider's redirect URL to its
+// callback route.
+//
+// Each Provider's temporary cookie is named after the CookieConfig Name with
+// a "-{name}" suffix, so logins started with different Providers in the same
+// browser do not clash. Use IdentityFromContext in the success handler to
+// read the user independent of the Provider.
+func (r *Registry) Mount(mux *http.ServeMux, config CookieConfig, success, failure http.Handler) {
+	if failure == nil {
+		failure = DefaultFailureHandler
+	}
+	for _, provider := range r.providers {
+		name := provider.Name()
+		providerConfig := config
+		providerConfig.Name = config.Name + "-" + name
+		mux.Handle("/"+name+"/login", provider.LoginHandler(providerConfig, failure))
+		mux.Handle("/"+name+"/callback", provider.CallbackHandler(providerConfig, success, failure))
+	}
+}
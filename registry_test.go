@@ -0,0 +1,62 @@
+package gologin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider writes the cookie name it was given so tests can check which
+// handler was mounted.
+type fakeProvider struct {
+	name string
+}
+
+func (p fakeProvider) Name() string {
+	return p.name
+}
+
+func (p fakeProvider) LoginHandler(config CookieConfig, failure http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "%s login %s", p.name, config.Name)
+	}
+	return http.HandlerFunc(fn)
+}
+
+func (p fakeProvider) CallbackHandler(config CookieConfig, success, failure http.Handler) http.Handler {
+	return success
+}
+
+func TestRegistry_Mount(t *testing.T) {
+	registry := NewRegistry(fakeProvider{"github"}, fakeProvider{"google"})
+	assert.Len(t, registry.Providers(), 2)
+	success := func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprintf(w, "success handler called")
+	}
+	mux := http.NewServeMux()
+	registry.Mount(mux, DebugOnlyCookieConfig, http.HandlerFunc(success), nil)
+
+	cases := []struct {
+		path     string
+		expected string
+	}{
+		{"/github/login", "github login gologin-temporary-cookie-github"},
+		{"/google/login", "google login gologin-temporary-cookie-google"},
+		{"/github/callback", "success handler called"},
+		{"/google/callback", "success handler called"},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", c.path, nil)
+		mux.ServeHTTP(w, req)
+		assert.Equal(t, c.expected, w.Body.String())
+	}
+	// unregistered providers are not mounted
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/twitter/login", nil)
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,27 @@
+package session
+
+import (
+	"context"
+	"fmt"
+)
+
+// unexported key type prevents collisions
+type key int
+
+const (
+	sessionKey key = iota
+)
+
+// WithSession returns a copy of ctx that stores the Session.
+func WithSession(ctx context.Context, session *Session) context.Context {
+	return context.WithValue(ctx, sessionKey, session)
+}
+
+// SessionFromContext returns the Session from the ctx.
+func SessionFromContext(ctx context.Context) (*Session, error) {
+	session, ok := ctx.Value(sessionKey).(*Session)
+	if !ok {
+		return nil, fmt.Errorf("session: Context missing Session")
+	}
+	return session, nil
+}
@@ -0,0 +1,24 @@
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextSession(t *testing.T) {
+	expected := &Session{ID: "id"}
+	ctx := WithSession(context.Background(), expected)
+	session, err := SessionFromContext(ctx)
+	assert.Equal(t, expected, session)
+	assert.Nil(t, err)
+}
+
+func TestContextSession_Error(t *testing.T) {
+	session, err := SessionFromContext(context.Background())
+	assert.Nil(t, session)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "session: Context missing Session", err.Error())
+	}
+}
@@ -0,0 +1,4 @@
+// Package session provides handlers which issue a signed (and optionally
+// encrypted) session cookie after login, require a valid session on
+// protected routes, and clear the session on logout.
+package session
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dghubble/gologin"
+)
+
+// IssueHandler issues a new Session for the gologin.Identity in the ctx,
+// adds it to the ctx, and calls the success handler (e.g. to redirect to a
+// profile page). Chain it as the success handler of a provider
+// CallbackHandler. On errors, handling delegates to the failure handler.
+//
+// Every login issues a Session with a new random ID, replacing any existing
+// session cookie. Sessions are kept only in the cookie, so the server keeps
+// no record of issued IDs and cannot revoke them: a copied cookie remains
+// valid until it expires, even after logout. Set short timeouts to limit it.
+func IssueHandler(config Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		identity, err := gologin.IdentityFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		session := New(identity)
+		if err := Save(w, config, session); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithSession(ctx, session)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// RequireLogin reads and verifies the Session cookie. If valid, the Session
+// and its gologin.Identity are added to the ctx and handling delegates to the
+// success handler. Otherwise, the failure handler is called (e.g. to redirect
+// to a login page) with an error such as ErrNoSession or ErrExpiredSession.
+//
+// If the config has an IdleTimeout, the session's last use is refreshed.
+func RequireLogin(config Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		session, err := Get(req, config)
+		if err != nil {
+			if err != ErrNoSession {
+				Destroy(w, config)
+			}
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if config.IdleTimeout > 0 {
+			session.LastSeen = time.Now()
+			if err := Save(w, config, session); err != nil {
+				ctx = gologin.WithError(ctx, err)
+				failure.ServeHTTP(w, req.WithContext(ctx))
+				return
+			}
+		}
+		ctx = WithSession(ctx, session)
+		ctx = gologin.WithIdentity(ctx, session.Identity)
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// LogoutHandler destroys the Session cookie on POST requests and calls the
+// success handler (e.g. to redirect home). Other methods are rejected with
+// 405 Method Not Allowed so links and prefetches cannot end a session.
+func LogoutHandler(config Config, success http.Handler) http.Handler {
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		Destroy(w, config)
+		success.ServeHTTP(w, req)
+	}
+	return http.HandlerFunc(fn)
+}
@@ -0,0 +1,206 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+)
+
+var testIdentity = &gologin.Identity{Provider: "github", Subject: "917408", Name: "Alyssa Hacker"}
+
+func testConfig() Config {
+	keyring, _ := gologin.NewKeyring([]byte("0123456789abcdef0123456789abcdef"))
+	config := DefaultConfig
+	config.Cookie.Keyring = keyring
+	return config
+}
+
+// issue runs the IssueHandler for the test Identity and returns the session
+// cookie.
+func issue(t *testing.T, config Config) *http.Cookie {
+	ctx := gologin.WithIdentity(context.Background(), testIdentity)
+	success := func(w http.ResponseWriter, req *http.Request) {}
+	handler := IssueHandler(config, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	cookies := w.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		t.FailNow()
+	}
+	return cookies[0]
+}
+
+func TestIssueHandler(t *testing.T) {
+	config := testConfig()
+	ctx := gologin.WithIdentity(context.Background(), testIdentity)
+	success := func(w http.ResponseWriter, req *http.Request) {
+		session, err := SessionFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.Equal(t, testIdentity, session.Identity)
+		assert.NotEmpty(t, session.ID)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// IssueHandler assert that:
+	// - a session cookie is set
+	// - the Session is added to the ctx of the success handler
+	handler := IssueHandler(config, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "gologin-session", cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+	}
+}
+
+func TestIssueHandler_RotatesID(t *testing.T) {
+	config := testConfig()
+	first := issue(t, config)
+	req, _ := http.NewRequest("GET", "/", nil)
+	req.AddCookie(first)
+	firstSession, err := Get(req, config)
+	assert.Nil(t, err)
+
+	// logging in again with an existing session issues a new ID
+	ctx := gologin.WithIdentity(context.Background(), testIdentity)
+	success := func(w http.ResponseWriter, req *http.Request) {
+		session, err := SessionFromContext(req.Context())
+		assert.Nil(t, err)
+		assert.NotEqual(t, firstSession.ID, session.ID)
+	}
+	handler := IssueHandler(config, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+}
+
+func TestIssueHandler_Errors(t *testing.T) {
+	cases := []struct {
+		config   Config
+		ctx      context.Context
+		expected error
+	}{
+		{testConfig(), context.Background(), fmt.Errorf("Context missing Identity")},
+		{DefaultConfig, gologin.WithIdentity(context.Background(), testIdentity), ErrMissingKeyring},
+	}
+	for _, c := range cases {
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			assert.Equal(t, c.expected, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+		handler := IssueHandler(c.config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(c.ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestRequireLogin(t *testing.T) {
+	config := testConfig()
+	config.EncryptionKey = []byte("0123456789abcdef")
+	cookie := issue(t, config)
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		session, err := SessionFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, testIdentity, session.Identity)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, testIdentity, identity)
+		fmt.Fprintf(w, "success handler called")
+	}
+
+	// RequireLogin assert that:
+	// - the encrypted session cookie is read and verified
+	// - the Session and Identity are added to the ctx
+	// - the session cookie is refreshed for the idle timeout
+	handler := RequireLogin(config, http.HandlerFunc(success), testutils.AssertFailureNotCalled(t))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/profile", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "success handler called", w.Body.String())
+	assert.Len(t, w.Result().Cookies(), 1)
+	// encrypted sessions do not reveal the Identity
+	assert.NotContains(t, cookie.Value, "Alyssa")
+}
+
+func TestRequireLogin_Errors(t *testing.T) {
+	config := testConfig()
+	now := time.Now()
+	idle := &Session{ID: "a", Identity: testIdentity, CreatedAt: now, LastSeen: now.Add(-time.Hour)}
+	old := &Session{ID: "b", Identity: testIdentity, CreatedAt: now.Add(-48 * time.Hour), LastSeen: now}
+	otherKeyring, _ := gologin.NewKeyring([]byte("abcdef0123456789abcdef0123456789"))
+	otherConfig := config
+	otherConfig.Cookie.Keyring = otherKeyring
+
+	cookieFor := func(config Config, session *Session) *http.Cookie {
+		w := httptest.NewRecorder()
+		assert.Nil(t, Save(w, config, session))
+		return w.Result().Cookies()[0]
+	}
+	cases := []struct {
+		cookie   *http.Cookie
+		expected error
+	}{
+		{nil, ErrNoSession},
+		{cookieFor(config, idle), ErrExpiredSession},
+		{cookieFor(config, old), ErrExpiredSession},
+		{cookieFor(otherConfig, New(testIdentity)), ErrInvalidSession},
+		{&http.Cookie{Name: "gologin-session", Value: "forged"}, ErrInvalidSession},
+	}
+	for _, c := range cases {
+		failure := func(w http.ResponseWriter, req *http.Request) {
+			err := gologin.ErrorFromContext(req.Context())
+			assert.Equal(t, c.expected, err)
+			fmt.Fprintf(w, "failure handler called")
+		}
+		handler := RequireLogin(config, testutils.AssertSuccessNotCalled(t), http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/profile", nil)
+		if c.cookie != nil {
+			req.AddCookie(c.cookie)
+		}
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	config := testConfig()
+	success := func(w http.ResponseWriter, req *http.Request) {
+		http.Redirect(w, req, "/", http.StatusFound)
+	}
+	handler := LogoutHandler(config, http.HandlerFunc(success))
+
+	// POST expires the session cookie
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/logout", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "gologin-session", cookies[0].Name)
+		assert.Equal(t, -1, cookies[0].MaxAge)
+	}
+
+	// GET does not log out or call the success handler
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/logout", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.HeaderMap.Get("Allow"))
+	assert.Empty(t, w.Result().Cookies())
+}
@@ -0,0 +1,164 @@
+package session
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal"
+)
+
+// Session errors
+var (
+	ErrMissingKeyring = errors.New("session: Cookie config requires a Keyring to sign sessions")
+	ErrNoSession      = errors.New("session: Request has no session")
+	ErrInvalidSession = errors.New("session: Invalid session")
+	ErrExpiredSession = errors.New("session: Expired session")
+)
+
+// Config configures session cookies.
+type Config struct {
+	// Cookie configures the session cookie. Its Keyring is required and signs
+	// session cookies so they cannot be forged or modified.
+	Cookie gologin.CookieConfig
+	// EncryptionKey optionally encrypts session cookies with AES-GCM so the
+	// Identity is not readable by the browser. It must be 16, 24, or 32 bytes
+	// long. Sessions are only signed if nil.
+	EncryptionKey []byte
+	// IdleTimeout expires sessions which have not been used for the duration.
+	// Zero means sessions never go idle.
+	IdleTimeout time.Duration
+	// AbsoluteTimeout expires sessions once the duration has passed since
+	// login, regardless of activity. Zero means no absolute timeout.
+	AbsoluteTimeout time.Duration
+}
+
+// DefaultConfig configures HTTPS-only session cookies which expire after 30
+// minutes of inactivity or 24 hours after login. Set a Keyring on the Cookie
+// before use.
+var DefaultConfig = Config{
+	Cookie: gologin.CookieConfig{
+		Name:     "gologin-session",
+		Path:     "/",
+		MaxAge:   86400, // 24 hours
+		HTTPOnly: true,
+		Secure:   true, // HTTPS only
+	},
+	IdleTimeout:     30 * time.Minute,
+	AbsoluteTimeout: 24 * time.Hour,
+}
+
+// Session is an authenticated user's session.
+type Session struct {
+	// ID is a random identifier which changes on every login.
+	ID string `json:"id"`
+	// Identity is the user who logged in.
+	Identity *gologin.Identity `json:"identity"`
+	// CreatedAt is the time of login.
+	CreatedAt time.Time `json:"created_at"`
+	// LastSeen is the time the session was last used.
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// New returns a new Session for the Identity with a fresh random ID.
+func New(identity *gologin.Identity) *Session {
+	now := time.Now()
+	return &Session{
+		ID:        internal.RandomValue(),
+		Identity:  identity,
+		CreatedAt: now,
+		LastSeen:  now,
+	}
+}
+
+// expired returns ErrExpiredSession if the session has exceeded the idle or
+// absolute timeout of the config.
+func (s *Session) expired(config Config, now time.Time) error {
+	if config.IdleTimeout > 0 && now.Sub(s.LastSeen) > config.IdleTimeout {
+		return ErrExpiredSession
+	}
+	if config.AbsoluteTimeout > 0 && now.Sub(s.CreatedAt) > config.AbsoluteTimeout {
+		return ErrExpiredSession
+	}
+	return nil
+}
+
+// Save writes the Session to a signed (and optionally encrypted) cookie.
+func Save(w http.ResponseWriter, config Config, session *Session) error {
+	if config.Cookie.Keyring == nil {
+		return ErrMissingKeyring
+	}
+	value, err := encode(config, session)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, internal.NewCookie(config.Cookie, value))
+	return nil
+}
+
+// Get reads and verifies the Session cookie of the request. Returns
+// ErrNoSession if there is no session cookie and ErrExpiredSession if the
+// session exceeded the idle or absolute timeout.
+func Get(req *http.Request, config Config) (*Session, error) {
+	if config.Cookie.Keyring == nil {
+		return nil, ErrMissingKeyring
+	}
+	value, err := internal.CookieValue(req, config.Cookie)
+	if err == http.ErrNoCookie {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+	session, err := decode(config, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := session.expired(config, time.Now()); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// Destroy expires the Session cookie.
+func Destroy(w http.ResponseWriter, config Config) {
+	http.SetCookie(w, internal.ExpiredCookie(config.Cookie))
+}
+
+// encode serializes the session and encrypts it if the config has an
+// EncryptionKey. The Keyring signs the result when the cookie is created.
+func encode(config Config, session *Session) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+	if config.EncryptionKey != nil {
+		data, err = internal.Seal(config.EncryptionKey, data, []byte(config.Cookie.Name))
+		if err != nil {
+			return "", err
+		}
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decode reverses encode.
+func decode(config Config, value string) (*Session, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidSession
+	}
+	if config.EncryptionKey != nil {
+		data, err = internal.Open(config.EncryptionKey, data, []byte(config.Cookie.Name))
+		if err != nil {
+			return nil, ErrInvalidSession
+		}
+	}
+	session := new(Session)
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, ErrInvalidSession
+	}
+	return session, nil
+}
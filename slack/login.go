@@ -3,11 +3,11 @@ package slack
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/dghubble/gologin"
 	oauth2Login "github.com/dghubble/gologin/oauth2"
 	"golang.org/x/oauth2"
-	"time"
 )
 
 // Slack login errors
@@ -29,14 +29,18 @@ func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler
 
 // LoginHandler handles Slack login requests by reading the state value
 // from the ctx and redirecting requests to the AuthURL with that state value.
-func LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
-	return oauth2Login.LoginHandler(config, failure)
+// The opts are added to the AuthURL (e.g. Team).
+func LoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
+	return oauth2Login.LoginHandler(config, failure, opts...)
 }
 
 // CallbackHandler handles Slack redirection URI requests and adds the
 // Slack access token and User to the ctx. If authentication succeeds,
 // handling delegates to the success handler, otherwise to the failure
 // handler.
+//
+// CallbackHandler gets the User with the legacy users.identity method. Use
+// OpenIDCallbackHandler for Sign in with Slack.
 func CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
 	success = slackHandler(config, success, failure)
 	return oauth2Login.CallbackHandler(config, success, failure)
@@ -58,8 +62,11 @@ func slackHandler(config *oauth2.Config, success, failure http.Handler) http.Han
 			failure.ServeHTTP(w, req.WithContext(ctx))
 			return
 		}
-		httpClient := &http.Client{Timeout: time.Second * 5}
-		slackService := newClient(httpClient, token)
+		httpClient := config.Client(ctx, token)
+		if httpClient.Timeout == 0 {
+			httpClient.Timeout = time.Second * 5
+		}
+		slackService := newClient(httpClient)
 		user, resp, err := slackService.Profile()
 		err = validateResponse(user, resp, err)
 		if err != nil {
@@ -68,6 +75,7 @@ func slackHandler(config *oauth2.Config, success, failure http.Handler) http.Han
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -84,3 +92,15 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Slack User.
+func newIdentity(user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:      "slack",
+		Subject:       user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+		AvatarURL:     user.Image192,
+	}
+}
@@ -15,8 +15,10 @@ import (
 )
 
 func TestSlackHandler(t *testing.T) {
-	jsonData := `{"id": "54638001", "name": "Ivy Crimson"}`
+	jsonData := `{"ok": true, "user": {"id": "54638001", "name": "Ivy Crimson"}, "team": {"id": "T0001", "name": "Acme"}}`
 	expectedUser := &User{ID: "54638001", Name: "Ivy Crimson"}
+	expectedUser.Team.ID = "T0001"
+	expectedUser.Team.Name = "Acme"
 	proxyClient, server := newSlackTestServer(jsonData)
 	defer server.Close()
 	// oauth2 Client will use the proxy client's base Transport
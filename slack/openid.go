@@ -0,0 +1,88 @@
+package slack
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Issuer is the Sign in with Slack OpenID Connect issuer.
+const Issuer = "https://slack.com"
+
+// OpenIDEndpoint is the Sign in with Slack OAuth2 endpoint. Configs using it
+// should request the "openid", "email", and "profile" scopes.
+//
+// ref: https://api.slack.com/authentication/sign-in-with-slack
+var OpenIDEndpoint = oauth2.Endpoint{
+	AuthURL:  "https://slack.com/openid/connect/authorize",
+	TokenURL: "https://slack.com/api/openid.connect.token",
+}
+
+// NewVerifier uses OpenID Connect discovery to return an oidc.Verifier of
+// Sign in with Slack ID Tokens issued to the client ID. The ctx is retained
+// to fetch signing keys.
+func NewVerifier(ctx context.Context, clientID string) (oidc.Verifier, error) {
+	return oidc.NewVerifier(ctx, Issuer, clientID)
+}
+
+// OpenIDLoginHandler handles Sign in with Slack login requests by reading the
+// state and nonce values from the ctx and redirecting requests to the AuthURL
+// with those values. Use oidc.CSRFHandler to add both to the ctx. The opts
+// are added to the AuthURL (e.g. Team).
+func OpenIDLoginHandler(config *oauth2.Config, failure http.Handler, opts ...oauth2.AuthCodeOption) http.Handler {
+	return oidc.LoginHandler(config, failure, opts...)
+}
+
+// OpenIDCallbackHandler handles Sign in with Slack redirection URI requests.
+// It verifies the ID Token (see oidc.IDTokenHandler) and adds the Slack
+// access token and User, with its team, Enterprise Grid organization, and
+// locale, to the ctx. The User is read from the ID Token claims alone, which
+// carry the same profile as openid.connect.userInfo, so no extra request is
+// made. If authentication succeeds, handling delegates to the success
+// handler, otherwise to the failure handler.
+//
+// The config Endpoint should be OpenIDEndpoint.
+func OpenIDCallbackHandler(config *oauth2.Config, verifier oidc.Verifier, success, failure http.Handler) http.Handler {
+	success = openIDHandler(success, failure)
+	success = oidc.IDTokenHandler(verifier, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// openIDHandler is a http.Handler that gets the verified ID Token from the
+// ctx to get the corresponding Slack User. If successful, the user is added
+// to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func openIDHandler(success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		idToken, err := oidc.IDTokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		claims := new(openIDClaims)
+		if err := idToken.Claims(claims); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		user := claims.user()
+		if user.ID == "" {
+			ctx = gologin.WithError(ctx, ErrUnableToGetSlackUser)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
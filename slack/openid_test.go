@@ -0,0 +1,123 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/internal/oidctest"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/oidc"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestOpenIDHandler(t *testing.T) {
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id_token": signer.IDToken(t, testClaims, map[string]interface{}{
+			"sub":                              "U0001",
+			"https://slack.com/user_id":        "U0001",
+			"https://slack.com/team_id":        "T0001",
+			"https://slack.com/team_name":      "Acme",
+			"https://slack.com/team_domain":    "acme",
+			"https://slack.com/enterprise_id":  "E0001",
+			"https://slack.com/user_image_192": "https://example.com/ivy.png",
+			"email":                            "ivy@example.com",
+			"email_verified":                   true,
+			"name":                             "Ivy Crimson",
+			"locale":                           "en-US",
+		}),
+	})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = oidc.WithNonce(ctx, testNonce)
+
+	expectedUser := &User{
+		ID:            "U0001",
+		Name:          "Ivy Crimson",
+		Email:         "ivy@example.com",
+		EmailVerified: true,
+		Locale:        "en-US",
+		TeamDomain:    "acme",
+		Image192:      "https://example.com/ivy.png",
+	}
+	expectedUser.Team.ID = "T0001"
+	expectedUser.Team.Name = "Acme"
+	expectedUser.Enterprise.ID = "E0001"
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		slackUser, err := UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, slackUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{
+			Provider:      "slack",
+			Subject:       "U0001",
+			Email:         "ivy@example.com",
+			EmailVerified: true,
+			Name:          "Ivy Crimson",
+			AvatarURL:     "https://example.com/ivy.png",
+		}, identity)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// IDTokenHandler and openIDHandler, assert that:
+	// - ID Token is verified
+	// - slack User is read from the ID Token claims and added to the ctx
+	// - success handler is called
+	handler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), openIDHandler(http.HandlerFunc(success), failure), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestOpenIDHandler_InvalidIDToken(t *testing.T) {
+	signer := oidctest.NewKeysServer(t)
+	defer signer.Close()
+	// ID Token issued to another client
+	token := (&oauth2.Token{AccessToken: "any-token"}).WithExtra(map[string]interface{}{
+		"id_token": signer.IDToken(t, testClaims, map[string]interface{}{"sub": "U0001", "aud": "other-client"}),
+	})
+	ctx := oauth2Login.WithToken(context.Background(), token)
+	ctx = oidc.WithNonce(ctx, testNonce)
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.NotNil(t, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := oidc.IDTokenHandler(signer.Verifier(Issuer, testClientID), openIDHandler(success, http.HandlerFunc(failure)), http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestOpenIDLoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: testClientID,
+		Endpoint: OpenIDEndpoint,
+	}
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+	ctx = oidc.WithNonce(ctx, testNonce)
+	loginHandler := OpenIDLoginHandler(config, testutils.AssertFailureNotCalled(t), Team("T0001"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, testNonce, location.Query().Get("nonce"))
+	assert.Equal(t, "T0001", location.Query().Get("team"))
+}
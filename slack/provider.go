@@ -0,0 +1,45 @@
+package slack
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/gologin/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "slack" which performs Slack
+// web logins with the given config, for mounting with a gologin.Registry.
+func Provider(config *oauth2.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+// OpenIDProvider returns a gologin.Provider named "slack" which performs Sign
+// in with Slack logins (see OpenIDCallbackHandler).
+func OpenIDProvider(config *oauth2.Config, verifier oidc.Verifier) gologin.Provider {
+	return &provider{config: config, verifier: verifier}
+}
+
+type provider struct {
+	config *oauth2.Config
+	// verifier is set for Sign in with Slack
+	verifier oidc.Verifier
+}
+
+func (p *provider) Name() string {
+	return "slack"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	if p.verifier != nil {
+		return oidc.CSRFHandler(config, OpenIDLoginHandler(p.config, failure))
+	}
+	return CSRFHandler(config, LoginHandler(p.config, failure))
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	if p.verifier != nil {
+		return oidc.CSRFHandler(config, OpenIDCallbackHandler(p.config, p.verifier, success, failure))
+	}
+	return CSRFHandler(config, CallbackHandler(p.config, success, failure))
+}
@@ -8,14 +8,32 @@ import (
 	"github.com/dghubble/gologin/testutils"
 )
 
+const (
+	testClientID = "client_id"
+	testNonce    = "nonce_val"
+)
+
 // newSlackTestServer returns a new httptest.Server which mocks the Slack
-// user endpoint and a client which proxies requests to the server. The server
-// responds with the given json data. The caller must close the server.
+// users.identity endpoint and a client which proxies requests to the server.
+// The server responds with the given json data to requests authorized with a
+// bearer token. The caller must close the server.
 func newSlackTestServer(jsonData string) (*http.Client, *httptest.Server) {
 	client, mux, server := testutils.TestServer()
-	mux.HandleFunc("/v2.4/me", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/api/users.identity", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") == "" || r.URL.Query().Get("token") != "" {
+			fmt.Fprint(w, `{"ok": false, "error": "not_authed"}`)
+			return
+		}
 		fmt.Fprintf(w, jsonData)
 	})
 	return client, server
 }
+
+// testClaims are the claims of a Sign in with Slack ID Token for
+// testClientID and testNonce.
+var testClaims = map[string]interface{}{
+	"iss":   Issuer,
+	"aud":   testClientID,
+	"nonce": testNonce,
+}
@@ -0,0 +1,91 @@
+package slack
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"golang.org/x/oauth2"
+)
+
+// Team returns an option which sends users to the Slack workspace with the
+// given team ID, instead of the workspace they last signed in to.
+func Team(teamID string) oauth2.AuthCodeOption {
+	return oauth2.SetAuthURLParam("team", teamID)
+}
+
+// TeamConfig lists the Slack workspaces and Enterprise Grid organizations
+// whose members may log in. If both are empty, no user may log in.
+type TeamConfig struct {
+	// TeamIDs are workspace (team) IDs.
+	TeamIDs []string
+	// EnterpriseIDs are Enterprise Grid organization IDs. Members of any
+	// workspace of the organization may log in.
+	EnterpriseIDs []string
+}
+
+// TeamError is returned when a Slack user is not a member of an allowed
+// workspace or Enterprise Grid organization.
+type TeamError struct {
+	// TeamID is the user's workspace ID.
+	TeamID string
+	// EnterpriseID is the user's Enterprise Grid organization ID, if any.
+	EnterpriseID string
+}
+
+func (e *TeamError) Error() string {
+	if e.EnterpriseID != "" {
+		return fmt.Sprintf("slack: team %s of enterprise %s is not allowed", e.TeamID, e.EnterpriseID)
+	}
+	return fmt.Sprintf("slack: team %s is not allowed", e.TeamID)
+}
+
+// TeamHandler is a http.Handler that checks the workspace and Enterprise Grid
+// organization of the Slack User in the ctx against the TeamConfig. Chain it
+// as the success handler of CallbackHandler or OpenIDCallbackHandler. If the
+// user is allowed, the success handler is called. Otherwise, the failure
+// handler is called with a *TeamError.
+//
+// Enterprise Grid organizations are only known with Sign in with Slack.
+func TeamHandler(teams TeamConfig, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		user, err := UserFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		if err := teams.check(user); err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// check returns a *TeamError if the User is not a member of an allowed
+// workspace or Enterprise Grid organization.
+func (c TeamConfig) check(user *User) error {
+	if user.Team.ID != "" && contains(c.TeamIDs, user.Team.ID) {
+		return nil
+	}
+	if user.Enterprise.ID != "" && contains(c.EnterpriseIDs, user.Enterprise.ID) {
+		return nil
+	}
+	return &TeamError{TeamID: user.Team.ID, EnterpriseID: user.Enterprise.ID}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestLoginHandler_Team(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: testClientID,
+		Endpoint: OpenIDEndpoint,
+	}
+	ctx := oauth2Login.WithState(context.Background(), "d4e5f6")
+	loginHandler := LoginHandler(config, testutils.AssertFailureNotCalled(t), Team("T0001"))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	loginHandler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "T0001", location.Query().Get("team"))
+}
+
+func TestTeamHandler(t *testing.T) {
+	teams := TeamConfig{TeamIDs: []string{"T0001"}, EnterpriseIDs: []string{"E0001"}}
+	cases := []struct {
+		teamID       string
+		enterpriseID string
+	}{
+		{"T0001", ""},
+		// any workspace of an allowed enterprise
+		{"T0002", "E0001"},
+	}
+	for _, c := range cases {
+		user := &User{ID: "U0001"}
+		user.Team.ID = c.teamID
+		user.Enterprise.ID = c.enterpriseID
+		ctx := WithUser(context.Background(), user)
+
+		success := func(w http.ResponseWriter, req *http.Request) {
+			fmt.Fprintf(w, "success handler called")
+		}
+		failure := testutils.AssertFailureNotCalled(t)
+
+		handler := TeamHandler(teams, http.HandlerFunc(success), failure)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "success handler called", w.Body.String())
+	}
+}
+
+func TestTeamHandler_NotAllowed(t *testing.T) {
+	user := &User{ID: "U0001"}
+	user.Team.ID = "T0003"
+	user.Enterprise.ID = "E0002"
+	ctx := WithUser(context.Background(), user)
+
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, &TeamError{TeamID: "T0003", EnterpriseID: "E0002"}, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	// TeamHandler with a user of another workspace or no allowed teams,
+	// assert that:
+	// - failure handler is called with a *TeamError
+	cases := []TeamConfig{
+		{TeamIDs: []string{"T0001"}, EnterpriseIDs: []string{"E0001"}},
+		{},
+	}
+	for _, teams := range cases {
+		handler := TeamHandler(teams, success, http.HandlerFunc(failure))
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		handler.ServeHTTP(w, req.WithContext(ctx))
+		assert.Equal(t, "failure handler called", w.Body.String())
+	}
+}
+
+func TestTeamHandler_MissingCtxUser(t *testing.T) {
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "slack: Context missing Slack User", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := TeamHandler(TeamConfig{TeamIDs: []string{"T0001"}}, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestTeamError(t *testing.T) {
+	assert.Equal(t, "slack: team T0003 is not allowed", (&TeamError{TeamID: "T0003"}).Error())
+	assert.Equal(t, "slack: team T0003 of enterprise E0002 is not allowed", (&TeamError{TeamID: "T0003", EnterpriseID: "E0002"}).Error())
+}
@@ -4,7 +4,6 @@ import (
 	"net/http"
 
 	"github.com/dghubble/sling"
-	"golang.org/x/oauth2"
 )
 
 const slackAPI = "https://slack.com/api/"
@@ -26,32 +25,36 @@ type User struct {
 		ID   string `json:"id"`
 		Name string `json:"name"`
 	} `json:"team"`
+	// EmailVerified, Locale, Team Domain, and Enterprise are only set by
+	// Sign in with Slack (OpenID Connect).
+	EmailVerified bool   `json:"email_verified"`
+	Locale        string `json:"locale"`
+	TeamDomain    string `json:"team_domain"`
+	// Enterprise is the user's Enterprise Grid organization, if any.
+	Enterprise struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"enterprise"`
 }
 
 // client is a Slack client for obtaining the current User.
 type client struct {
 	c     *http.Client
 	sling *sling.Sling
-	t     *oauth2.Token
 }
 
-func newClient(httpClient *http.Client, t *oauth2.Token) *client {
+// newClient returns a Slack client. The httpClient must be authorized with the
+// access token (e.g. config.Client(ctx, token)).
+func newClient(httpClient *http.Client) *client {
 	base := sling.New().Client(httpClient).Base(slackAPI)
 	return &client{
 		c:     httpClient,
 		sling: base,
-		t:     t,
 	}
 }
 
+// Profile gets the User with the legacy users.identity method.
 func (c *client) Profile() (*User, *http.Response, error) {
-	type Params struct {
-		Token string `url:"token,omitempty"`
-	}
-	q := &Params{
-		Token: c.t.AccessToken,
-	}
-
 	type UserIdentity struct {
 		Ok   bool `json:"ok"`
 		User struct {
@@ -75,7 +78,7 @@ func (c *client) Profile() (*User, *http.Response, error) {
 	// Slack returns JSON as Content-Type text/javascript :(
 	// Set Accept header to receive proper Content-Type application/json
 	// so Sling will decode into the struct
-	resp, err := c.sling.New().Set("Accept", "application/json").Get("users.identity").QueryStruct(q).ReceiveSuccess(ui)
+	resp, err := c.sling.New().Set("Accept", "application/json").Get("users.identity").ReceiveSuccess(ui)
 	user := new(User)
 	if ui.Ok {
 		user.ID = ui.User.ID
@@ -93,3 +96,54 @@ func (c *client) Profile() (*User, *http.Response, error) {
 	}
 	return user, resp, err
 }
+
+// openIDClaims are the claims of Sign in with Slack ID Tokens. The User is
+// read from these verified claims, so openid.connect.userInfo is not called.
+//
+// ref: https://api.slack.com/authentication/sign-in-with-slack
+type openIDClaims struct {
+	Subject        string `json:"sub"`
+	UserID         string `json:"https://slack.com/user_id"`
+	TeamID         string `json:"https://slack.com/team_id"`
+	TeamName       string `json:"https://slack.com/team_name"`
+	TeamDomain     string `json:"https://slack.com/team_domain"`
+	EnterpriseID   string `json:"https://slack.com/enterprise_id"`
+	EnterpriseName string `json:"https://slack.com/enterprise_name"`
+	Email          string `json:"email"`
+	EmailVerified  bool   `json:"email_verified"`
+	Name           string `json:"name"`
+	Picture        string `json:"picture"`
+	Locale         string `json:"locale"`
+	Image24        string `json:"https://slack.com/user_image_24"`
+	Image32        string `json:"https://slack.com/user_image_32"`
+	Image48        string `json:"https://slack.com/user_image_48"`
+	Image72        string `json:"https://slack.com/user_image_72"`
+	Image192       string `json:"https://slack.com/user_image_192"`
+	Image512       string `json:"https://slack.com/user_image_512"`
+}
+
+// user returns the User of the claims.
+func (c *openIDClaims) user() *User {
+	user := &User{
+		ID:            c.UserID,
+		Name:          c.Name,
+		Email:         c.Email,
+		EmailVerified: c.EmailVerified,
+		Locale:        c.Locale,
+		TeamDomain:    c.TeamDomain,
+		Image24:       c.Image24,
+		Image32:       c.Image32,
+		Image48:       c.Image48,
+		Image72:       c.Image72,
+		Image192:      c.Image192,
+		Image512:      c.Image512,
+	}
+	if user.ID == "" {
+		user.ID = c.Subject
+	}
+	user.Team.ID = c.TeamID
+	user.Team.Name = c.TeamName
+	user.Enterprise.ID = c.EnterpriseID
+	user.Enterprise.Name = c.EnterpriseName
+	return user
+}
@@ -61,6 +61,7 @@ func tumblrHandler(config *oauth1.Config, success, failure http.Handler) http.Ha
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -77,3 +78,14 @@ func validateResponse(user *User, resp *http.Response, err error) error {
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Tumblr User. Tumblr does not
+// expose a numeric user ID, so the primary blog name is the Subject.
+func newIdentity(user *User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider: "tumblr",
+		Subject:  user.Name,
+		Name:     user.Name,
+		Username: user.Name,
+	}
+}
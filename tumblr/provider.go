@@ -0,0 +1,31 @@
+package tumblr
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/oauth1"
+)
+
+// Provider returns a gologin.Provider named "tumblr" which performs Tumblr
+// web logins with the given config, for mounting with a gologin.Registry.
+// The CookieConfig names the temporary request secret cookie.
+func Provider(config *oauth1.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+type provider struct {
+	config *oauth1.Config
+}
+
+func (p *provider) Name() string {
+	return "tumblr"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return LoginHandler(p.config, config, failure)
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CallbackHandler(p.config, config, success, failure)
+}
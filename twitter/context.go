@@ -12,6 +12,7 @@ type key int
 
 const (
 	userKey key = iota
+	oauth2UserKey
 )
 
 // WithUser returns a copy of ctx that stores the Twitter User.
@@ -27,3 +28,17 @@ func UserFromContext(ctx context.Context) (*twitter.User, error) {
 	}
 	return user, nil
 }
+
+// WithOAuth2User returns a copy of ctx that stores the Twitter OAuth2User.
+func WithOAuth2User(ctx context.Context, user *OAuth2User) context.Context {
+	return context.WithValue(ctx, oauth2UserKey, user)
+}
+
+// OAuth2UserFromContext returns the Twitter OAuth2User from the ctx.
+func OAuth2UserFromContext(ctx context.Context) (*OAuth2User, error) {
+	user, ok := ctx.Value(oauth2UserKey).(*OAuth2User)
+	if !ok {
+		return nil, fmt.Errorf("twitter: Context missing Twitter OAuth2User")
+	}
+	return user, nil
+}
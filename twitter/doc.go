@@ -1,2 +1,3 @@
-// Package twitter provides Twitter OAuth1 login, callback, and token handlers.
+// Package twitter provides Twitter OAuth1 login, callback, and token handlers
+// and OAuth 2.0 (with PKCE) login and callback handlers.
 package twitter
@@ -65,6 +65,7 @@ func twitterHandler(config *oauth1.Config, success, failure http.Handler) http.H
 			return
 		}
 		ctx = WithUser(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newIdentity(user))
 		success.ServeHTTP(w, req.WithContext(ctx))
 	}
 	return http.HandlerFunc(fn)
@@ -81,3 +82,15 @@ func validateResponse(user *twitter.User, resp *http.Response, err error) error
 	}
 	return nil
 }
+
+// newIdentity returns the Identity of the Twitter User.
+func newIdentity(user *twitter.User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:  "twitter",
+		Subject:   user.IDStr,
+		Email:     user.Email,
+		Name:      user.Name,
+		Username:  user.ScreenName,
+		AvatarURL: user.ProfileImageURLHttps,
+	}
+}
@@ -0,0 +1,115 @@
+package twitter
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"golang.org/x/oauth2"
+)
+
+// OAuth2Endpoint is the Twitter OAuth 2.0 endpoint. Twitter requires PKCE,
+// which CSRFHandler provides.
+//
+// ref: https://developer.twitter.com/en/docs/authentication/oauth-2-0/authorization-code
+var OAuth2Endpoint = oauth2.Endpoint{
+	AuthURL:   "https://twitter.com/i/oauth2/authorize",
+	TokenURL:  "https://api.twitter.com/2/oauth2/token",
+	AuthStyle: oauth2.AuthStyleInHeader,
+}
+
+// Twitter OAuth 2.0 scopes. Getting the OAuth2User requires ScopeUsersRead
+// and ScopeTweetRead.
+const (
+	ScopeUsersRead = "users.read"
+	ScopeTweetRead = "tweet.read"
+	// ScopeOfflineAccess requests a refresh token with the access token,
+	// which otherwise expires after two hours.
+	ScopeOfflineAccess = "offline.access"
+)
+
+// CSRFHandler checks for a state cookie. If found, the state value is read
+// and added to the ctx. Otherwise, a non-guessable value is added to the ctx
+// and to a (short-lived) state cookie issued to the requester. A PKCE code
+// verifier cookie is read the same way, but only issued by
+// OAuth2LoginHandler. Twitter requires PKCE, so the CookieConfig DisablePKCE
+// field is ignored.
+//
+// Only OAuth 2.0 logins use CSRFHandler, since OAuth1 logins are protected by
+// their request token.
+func CSRFHandler(config gologin.CookieConfig, success http.Handler) http.Handler {
+	config.DisablePKCE = false
+	return oauth2Login.CSRFHandler(config, success)
+}
+
+// OAuth2LoginHandler handles Twitter OAuth 2.0 login requests by reading the
+// state value from the ctx, issuing a PKCE code verifier, and redirecting
+// requests to the AuthURL with the state and code challenge.
+func OAuth2LoginHandler(config *oauth2.Config, failure http.Handler) http.Handler {
+	return oauth2Login.LoginHandler(config, failure)
+}
+
+// OAuth2CallbackHandler handles Twitter OAuth 2.0 redirection URI requests and
+// adds the Twitter access token and OAuth2User to the ctx. If the config
+// Scopes include ScopeOfflineAccess, the token has a refresh token (e.g. for
+// config.TokenSource or oauth2.StoredTokenSource). If authentication
+// succeeds, handling delegates to the success handler, otherwise to the
+// failure handler.
+func OAuth2CallbackHandler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	success = oauth2Handler(config, success, failure)
+	return oauth2Login.CallbackHandler(config, success, failure)
+}
+
+// oauth2Handler is a http.Handler that gets the OAuth2 Token from the ctx to
+// get the corresponding Twitter OAuth2User. If successful, the user is added
+// to the ctx and the success handler is called. Otherwise, the failure
+// handler is called.
+func oauth2Handler(config *oauth2.Config, success, failure http.Handler) http.Handler {
+	if failure == nil {
+		failure = gologin.DefaultFailureHandler
+	}
+	fn := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		twitterClient := newClient(config.Client(ctx, token))
+		user, resp, err := twitterClient.Me()
+		err = validateOAuth2Response(user, resp, err)
+		if err != nil {
+			ctx = gologin.WithError(ctx, err)
+			failure.ServeHTTP(w, req.WithContext(ctx))
+			return
+		}
+		ctx = WithOAuth2User(ctx, user)
+		ctx = gologin.WithIdentity(ctx, newOAuth2Identity(user))
+		success.ServeHTTP(w, req.WithContext(ctx))
+	}
+	return http.HandlerFunc(fn)
+}
+
+// validateOAuth2Response returns an error if the given Twitter OAuth2User, raw
+// http.Response, or error are unexpected. Returns nil if they are valid.
+func validateOAuth2Response(user *OAuth2User, resp *http.Response, err error) error {
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return ErrUnableToGetTwitterUser
+	}
+	if user == nil || user.ID == "" {
+		return ErrUnableToGetTwitterUser
+	}
+	return nil
+}
+
+// newOAuth2Identity returns the Identity of the Twitter OAuth2User.
+func newOAuth2Identity(user *OAuth2User) *gologin.Identity {
+	return &gologin.Identity{
+		Provider:  "twitter",
+		Subject:   user.ID,
+		Name:      user.Name,
+		Username:  user.Username,
+		AvatarURL: user.ProfileImageURL,
+	}
+}
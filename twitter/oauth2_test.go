@@ -0,0 +1,153 @@
+package twitter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/dghubble/gologin"
+	oauth2Login "github.com/dghubble/gologin/oauth2"
+	"github.com/dghubble/gologin/testutils"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/oauth2"
+)
+
+func TestOAuth2LoginHandler(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: OAuth2Endpoint,
+		Scopes:   []string{ScopeUsersRead, ScopeTweetRead, ScopeOfflineAccess},
+	}
+	success := OAuth2LoginHandler(config, testutils.AssertFailureNotCalled(t))
+
+	// CSRFHandler -> OAuth2LoginHandler, assert that:
+	// - the user is redirected with a state and S256 code challenge
+	handler := CSRFHandler(gologin.DebugOnlyCookieConfig, success)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.Equal(t, "twitter.com", location.Host)
+	assert.NotEmpty(t, location.Query().Get("state"))
+	assert.NotEmpty(t, location.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+	assert.Equal(t, "users.read tweet.read offline.access", location.Query().Get("scope"))
+}
+
+func TestCSRFHandler_IgnoresDisablePKCE(t *testing.T) {
+	config := &oauth2.Config{
+		ClientID: "client_id",
+		Endpoint: OAuth2Endpoint,
+	}
+	success := OAuth2LoginHandler(config, testutils.AssertFailureNotCalled(t))
+	cookieConfig := gologin.DebugOnlyCookieConfig
+	cookieConfig.DisablePKCE = true
+
+	// CSRFHandler -> OAuth2LoginHandler, assert that:
+	// - Twitter still sends the PKCE code challenge
+	handler := CSRFHandler(cookieConfig, success)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusFound, w.Code)
+	location, err := url.Parse(w.HeaderMap.Get("Location"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, location.Query().Get("code_challenge"))
+	assert.Equal(t, "S256", location.Query().Get("code_challenge_method"))
+}
+
+func TestOAuth2CallbackHandler(t *testing.T) {
+	jsonData := `{"data": {"id": "2244994945", "name": "Twitter Dev", "username": "TwitterDev", "profile_image_url": "https://example.com/dev.png"}}`
+	expectedUser := &OAuth2User{ID: "2244994945", Name: "Twitter Dev", Username: "TwitterDev", ProfileImageURL: "https://example.com/dev.png"}
+	proxyClient, server := newTwitterOAuth2Server(jsonData, "verifier_val")
+	defer server.Close()
+	// oauth2 Client will use the proxy client's base Transport
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithState(ctx, "d4e5f6")
+	ctx = oauth2Login.WithVerifier(ctx, "verifier_val")
+
+	config := &oauth2.Config{
+		ClientID:     "client_id",
+		ClientSecret: "client_secret",
+		Endpoint:     OAuth2Endpoint,
+	}
+	success := func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		token, err := oauth2Login.TokenFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, "any-refresh-token", token.RefreshToken)
+		twitterUser, err := OAuth2UserFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedUser, twitterUser)
+		identity, err := gologin.IdentityFromContext(ctx)
+		assert.Nil(t, err)
+		assert.Equal(t, &gologin.Identity{Provider: "twitter", Subject: "2244994945", Name: "Twitter Dev", Username: "TwitterDev", AvatarURL: "https://example.com/dev.png"}, identity)
+		fmt.Fprintf(w, "success handler called")
+	}
+	failure := testutils.AssertFailureNotCalled(t)
+
+	// OAuth2CallbackHandler assert that:
+	// - the auth code is exchanged with the PKCE code verifier
+	// - the token (with refresh token) and OAuth2User are added to the ctx
+	// - success handler is called
+	handler := OAuth2CallbackHandler(config, http.HandlerFunc(success), failure)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/?code=any_code&state=d4e5f6", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "success handler called", w.Body.String())
+}
+
+func TestOAuth2Handler_MissingCtxToken(t *testing.T) {
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		if assert.NotNil(t, err) {
+			assert.Equal(t, "oauth2: Context missing Token", err.Error())
+		}
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := oauth2Handler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req)
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestOAuth2Handler_ErrorGettingUser(t *testing.T) {
+	proxyClient, server := testutils.NewErrorServer("Twitter Service Down", http.StatusInternalServerError)
+	defer server.Close()
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, proxyClient)
+	ctx = oauth2Login.WithToken(ctx, &oauth2.Token{AccessToken: "any-token"})
+
+	config := &oauth2.Config{}
+	success := testutils.AssertSuccessNotCalled(t)
+	failure := func(w http.ResponseWriter, req *http.Request) {
+		err := gologin.ErrorFromContext(req.Context())
+		assert.Equal(t, ErrUnableToGetTwitterUser, err)
+		fmt.Fprintf(w, "failure handler called")
+	}
+
+	handler := oauth2Handler(config, success, http.HandlerFunc(failure))
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, req.WithContext(ctx))
+	assert.Equal(t, "failure handler called", w.Body.String())
+}
+
+func TestValidateOAuth2Response(t *testing.T) {
+	validUser := &OAuth2User{ID: "2244994945"}
+	validResponse := &http.Response{StatusCode: 200}
+	invalidResponse := &http.Response{StatusCode: 500}
+	assert.Equal(t, nil, validateOAuth2Response(validUser, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateOAuth2Response(validUser, validResponse, fmt.Errorf("Server error")))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateOAuth2Response(validUser, invalidResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateOAuth2Response(nil, validResponse, nil))
+	assert.Equal(t, ErrUnableToGetTwitterUser, validateOAuth2Response(&OAuth2User{}, validResponse, nil))
+}
@@ -0,0 +1,56 @@
+package twitter
+
+import (
+	"net/http"
+
+	"github.com/dghubble/gologin"
+	"github.com/dghubble/oauth1"
+	"golang.org/x/oauth2"
+)
+
+// Provider returns a gologin.Provider named "twitter" which performs Twitter
+// web logins with the given config, for mounting with a gologin.Registry.
+// Twitter logins do not need temporary cookies, so the CookieConfig is
+// unused.
+func Provider(config *oauth1.Config) gologin.Provider {
+	return &provider{config: config}
+}
+
+type provider struct {
+	config *oauth1.Config
+}
+
+func (p *provider) Name() string {
+	return "twitter"
+}
+
+func (p *provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return LoginHandler(p.config, failure)
+}
+
+func (p *provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CallbackHandler(p.config, success, failure)
+}
+
+// OAuth2Provider returns a gologin.Provider named "twitter" which performs
+// Twitter OAuth 2.0 web logins with the given config, for mounting with a
+// gologin.Registry.
+func OAuth2Provider(config *oauth2.Config) gologin.Provider {
+	return &oauth2Provider{config: config}
+}
+
+type oauth2Provider struct {
+	config *oauth2.Config
+}
+
+func (p *oauth2Provider) Name() string {
+	return "twitter"
+}
+
+func (p *oauth2Provider) LoginHandler(config gologin.CookieConfig, failure http.Handler) http.Handler {
+	return CSRFHandler(config, OAuth2LoginHandler(p.config, failure))
+}
+
+func (p *oauth2Provider) CallbackHandler(config gologin.CookieConfig, success, failure http.Handler) http.Handler {
+	return CSRFHandler(config, OAuth2CallbackHandler(p.config, success, failure))
+}
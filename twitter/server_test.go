@@ -20,3 +20,32 @@ func newTwitterVerifyServer(jsonData string) (*http.Client, *http.ServeMux, *htt
 	})
 	return client, mux, server
 }
+
+// newTwitterOAuth2Server returns a new httptest.Server which mocks the Twitter
+// API v2 token and users/me endpoints and a client which proxies requests to
+// the server. The token endpoint requires the PKCE code verifier and responds
+// with a refresh token. The users/me endpoint responds with the given json
+// data. The caller must close the server.
+func newTwitterOAuth2Server(jsonData, verifier string) (*http.Client, *httptest.Server) {
+	client, mux, server := testutils.TestServer()
+	mux.HandleFunc("/2/oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("code_verifier") != verifier {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": "invalid_request"}`)
+			return
+		}
+		fmt.Fprint(w, `{"token_type": "bearer", "access_token": "any-token", "refresh_token": "any-refresh-token", "expires_in": 7200, "scope": "users.read tweet.read offline.access"}`)
+	})
+	mux.HandleFunc("/2/users/me", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("Authorization") != "Bearer any-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"title": "Unauthorized"}`)
+			return
+		}
+		fmt.Fprint(w, jsonData)
+	})
+	return client, server
+}
@@ -0,0 +1,58 @@
+package twitter
+
+import (
+	"net/http"
+
+	"github.com/dghubble/sling"
+)
+
+const twitterAPI = "https://api.twitter.com/2/"
+
+// OAuth2User is a Twitter user from the Twitter API v2.
+//
+// ref: https://developer.twitter.com/en/docs/twitter-api/data-dictionary/object-model/user
+type OAuth2User struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	Username        string `json:"username"`
+	ProfileImageURL string `json:"profile_image_url"`
+	Description     string `json:"description"`
+	Location        string `json:"location"`
+	URL             string `json:"url"`
+	Verified        bool   `json:"verified"`
+	Protected       bool   `json:"protected"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// userParams request OAuth2User fields beyond the defaults.
+type userParams struct {
+	UserFields string `url:"user.fields"`
+}
+
+// client is a Twitter API v2 client for obtaining the current OAuth2User.
+type client struct {
+	c     *http.Client
+	sling *sling.Sling
+}
+
+// newClient returns a Twitter API v2 client. The httpClient must be
+// authorized with an OAuth2 access token (e.g. config.Client(ctx, token)).
+func newClient(httpClient *http.Client) *client {
+	base := sling.New().Client(httpClient).Base(twitterAPI)
+	return &client{
+		c:     httpClient,
+		sling: base,
+	}
+}
+
+// Me gets the authenticated OAuth2User.
+func (c *client) Me() (*OAuth2User, *http.Response, error) {
+	params := &userParams{
+		UserFields: "profile_image_url,description,location,url,verified,protected,created_at",
+	}
+	body := new(struct {
+		Data *OAuth2User `json:"data"`
+	})
+	resp, err := c.sling.New().Get("users/me").QueryStruct(params).ReceiveSuccess(body)
+	return body.Data, resp, err
+}